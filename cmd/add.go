@@ -2,12 +2,13 @@ package cmd
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/telikz/spacdr/internal/app"
 	"github.com/telikz/spacdr/internal/config"
+	"github.com/telikz/spacdr/internal/repo"
 )
 
 var addCategory string
@@ -24,11 +25,15 @@ var AddCmd = &cobra.Command{
 			return fmt.Errorf("deck file not found: %s", sourcePath)
 		}
 
-		sourceFile, err := os.Open(sourcePath)
+		// Warnings are shown, but only errors keep the deck out.
+		problems, err := repo.ValidateDeckFile(sourcePath)
 		if err != nil {
-			return fmt.Errorf("error opening deck file: %w", err)
+			return fmt.Errorf("error reading deck file: %w", err)
+		}
+		printProblems(sourcePath, problems)
+		if repo.HasErrors(problems) {
+			return fmt.Errorf("%s has errors, fix them before adding it", sourcePath)
 		}
-		defer sourceFile.Close()
 
 		fileName := filepath.Base(sourcePath)
 		var destPath string
@@ -44,26 +49,52 @@ var AddCmd = &cobra.Command{
 			destPath = filepath.Join(spacdrDir, fileName)
 		}
 
-		destFile, err := os.Create(destPath)
-		if err != nil {
-			return fmt.Errorf("error creating destination file: %w", err)
+		if sameFile(sourcePath, destPath) {
+			return fmt.Errorf("%s is already in the .spacdr directory", sourcePath)
 		}
-		defer destFile.Close()
 
-		if _, err := io.Copy(destFile, sourceFile); err != nil {
-			return fmt.Errorf("error copying deck file: %w", err)
+		lock, err := app.LockDeck(destPath)
+		if err != nil {
+			return fmt.Errorf("can't replace %s: %w", destPath, err)
 		}
+		defer lock.Unlock()
 
 		relativePath := filepath.Base(destPath)
 		if addCategory != "" {
 			relativePath = filepath.Join(addCategory, relativePath)
 		}
 
+		database, err := app.UsesDatabase()
+		if err != nil {
+			return err
+		}
+		if database {
+			if err := app.ImportDeck(sourcePath, destPath); err != nil {
+				return fmt.Errorf("error importing deck file: %w", err)
+			}
+			fmt.Printf("✓ Deck added to %s\n", relativePath)
+			return nil
+		}
+
+		if err := app.AddDeckFile(sourcePath, destPath); err != nil {
+			return fmt.Errorf("error copying deck file: %w", err)
+		}
+
 		fmt.Printf("✓ Deck added to %s\n", relativePath)
 		return nil
 	},
 }
 
+// sameFile reports whether the paths are the same existing file.
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	return err == nil && os.SameFile(infoA, infoB)
+}
+
 func init() {
 	AddCmd.Flags().StringVar(&addCategory, "category", "", "category to organize the deck (optional)")
 	RootCmd.AddCommand(AddCmd)
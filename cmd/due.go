@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/telikz/spacdr/internal/app"
+	"github.com/telikz/spacdr/internal/config"
+)
+
+var DueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "Show how many cards are due in each deck",
+	Long:  "Show how many reviews are due and how many new cards are available today in each deck, within the daily limits",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := app.NewDeckService()
+		if err != nil {
+			return err
+		}
+
+		refs, err := allDeckRefs()
+		if err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			fmt.Println("No decks found in .spacdr/")
+			return nil
+		}
+
+		now := time.Now()
+		totalReviews, totalNew := 0, 0
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Deck\tDue\tNew\tStudied today\tNext review")
+		for _, ref := range refs {
+			fullPath := config.GetDeckPath(ref)
+			deck, err := svc.LoadDeck(fullPath)
+			if err != nil {
+				return fmt.Errorf("error loading deck from %s: %w", fullPath, err)
+			}
+			due, err := svc.Due(deck, now)
+			if err != nil {
+				return fmt.Errorf("deck %s: %w", ref, err)
+			}
+
+			next := "-"
+			if due.Reviews > 0 {
+				next = "now"
+			} else if !due.NextDue.IsZero() {
+				next = app.FormatUntil(due.NextDue.Sub(now))
+			}
+			studied := fmt.Sprintf("%d/%d reviews, %d/%d new",
+				due.ReviewsDone, due.Limits.ReviewsPerDay, due.NewDone, due.Limits.NewCardsPerDay)
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", ref, due.Reviews, due.New, studied, next)
+
+			totalReviews += due.Reviews
+			totalNew += due.New
+		}
+		if len(refs) > 1 {
+			fmt.Fprintf(w, "Total\t%d\t%d\n", totalReviews, totalNew)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(DueCmd)
+}
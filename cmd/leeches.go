@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/telikz/spacdr/internal/app"
+	"github.com/telikz/spacdr/internal/config"
+)
+
+// leechFrontWidth is where card fronts are cut off in the leech table.
+const leechFrontWidth = 40
+
+var LeechesCmd = &cobra.Command{
+	Use:   "leeches [deck]",
+	Short: "List cards that keep being failed",
+	Long:  "List the leeches of one deck (e.g. 'spanish/vocabulary') or of all decks, with how often and when they were failed",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := app.NewDeckService()
+		if err != nil {
+			return err
+		}
+
+		var refs []string
+		if len(args) == 1 {
+			refs = []string{args[0]}
+		} else {
+			refs, err = allDeckRefs()
+			if err != nil {
+				return err
+			}
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Deck\tCard\tFront\tLapses\tFailed\tLast failed\tSuspended")
+		found := 0
+		for _, ref := range refs {
+			fullPath := config.GetDeckPath(ref)
+			deck, err := svc.LoadDeck(fullPath)
+			if err != nil {
+				return fmt.Errorf("error loading deck from %s: %w", fullPath, err)
+			}
+			leeches, err := svc.Leeches(deck)
+			if err != nil {
+				return fmt.Errorf("deck %s: %w", ref, err)
+			}
+
+			for _, leech := range leeches {
+				lastFailed := "-"
+				if n := len(leech.Failures); n > 0 {
+					lastFailed = leech.Failures[n-1].Format("2006-01-02")
+				}
+				suspended := "no"
+				if leech.Suspended {
+					suspended = "yes"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d/%d reviews\t%s\t%s\n",
+					ref, leech.CardID, truncate(leech.Front, leechFrontWidth), leech.Lapses,
+					len(leech.Failures), leech.Reviews, lastFailed, suspended)
+				found++
+			}
+		}
+
+		if found == 0 {
+			fmt.Println("No leeches found")
+			return nil
+		}
+		return w.Flush()
+	},
+}
+
+// truncate shortens s to at most n characters on a single line.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	for i, r := range runes {
+		if r == '\n' {
+			runes = runes[:i]
+			break
+		}
+	}
+	if len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return string(runes)
+}
+
+func init() {
+	RootCmd.AddCommand(LeechesCmd)
+}
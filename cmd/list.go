@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
-	"github.com/telikz/spacdr/internal/config"
+	"github.com/telikz/spacdr/internal/app"
+	"github.com/telikz/spacdr/internal/domain"
 )
 
 var ListCmd = &cobra.Command{
@@ -12,7 +16,7 @@ var ListCmd = &cobra.Command{
 	Short: "List all available decks",
 	Long:  "List all available decks in the .spacdr directory organized by category",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		categoryDecks, err := config.DiscoverDecks()
+		categoryDecks, err := app.DiscoverDecks()
 		if err != nil {
 			return err
 		}
@@ -22,13 +26,32 @@ var ListCmd = &cobra.Command{
 			return nil
 		}
 
+		svc, err := app.NewDeckService()
+		if err != nil {
+			return err
+		}
+
 		fmt.Println("Available Decks:")
 		fmt.Println()
 
 		for _, cd := range categoryDecks {
 			fmt.Printf("📂 %s\n", cd.Category)
-			for _, deck := range cd.Decks {
-				fmt.Printf("   └─ %s (%s)\n", deck.Name, deck.RelativePath)
+			for _, info := range cd.Decks {
+				// A broken deck is still listed; studying it reports the error.
+				deck, err := svc.LoadDeck(info.FullPath)
+				if err != nil {
+					fmt.Printf("   └─ %s (%s)\n", info.Name, info.RelativePath)
+					continue
+				}
+
+				suspended := ""
+				if n := countSuspended(deck); n > 0 {
+					suspended = fmt.Sprintf(" • %d suspended", n)
+				}
+				fmt.Printf("   └─ %s (%s)%s\n", info.Name, info.RelativePath, suspended)
+				if tags := formatTagCounts(deck.TagCounts()); tags != "" {
+					fmt.Printf("      tags: %s\n", tags)
+				}
 			}
 			fmt.Println()
 		}
@@ -37,6 +60,30 @@ var ListCmd = &cobra.Command{
 	},
 }
 
+func countSuspended(deck *domain.Deck) int {
+	n := 0
+	for i := range deck.Cards {
+		if deck.Cards[i].Suspended {
+			n++
+		}
+	}
+	return n
+}
+
+// formatTagCounts lists tags with their card counts, most used first.
+func formatTagCounts(counts map[string]int) string {
+	tags := slices.Sorted(maps.Keys(counts))
+	slices.SortStableFunc(tags, func(a, b string) int {
+		return counts[b] - counts[a]
+	})
+
+	parts := make([]string, len(tags))
+	for i, tag := range tags {
+		parts[i] = fmt.Sprintf("%s (%d)", tag, counts[tag])
+	}
+	return strings.Join(parts, ", ")
+}
+
 func init() {
 	RootCmd.AddCommand(ListCmd)
 }
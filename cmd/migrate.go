@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/telikz/spacdr/internal/app"
+	"github.com/telikz/spacdr/internal/config"
+)
+
+var migrateTo string
+
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate --to sqlite|json",
+	Short: "Move the collection between deck files and the database",
+	Long:  "Copy every deck, with its progress and review log, from the deck files in the .spacdr directory into the spacdr database (--to sqlite), or from the database back to deck files (--to json). Decks already at the destination are replaced. Set backend in config.yaml to use the result",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var paths []string
+		var err error
+		switch migrateTo {
+		case config.BackendSQLite:
+			paths, err = app.MigrateToSQLite()
+		case config.BackendJSON:
+			paths, err = app.MigrateToJSON()
+		default:
+			return fmt.Errorf("unknown backend %q (available: %s, %s)", migrateTo, config.BackendJSON, config.BackendSQLite)
+		}
+		if err != nil {
+			return fmt.Errorf("error migrating to %s: %w", migrateTo, err)
+		}
+
+		fmt.Printf("✓ Migrated %d decks to %s\n", len(paths), migrateTo)
+		if backend, _ := config.GetBackend(); backend != migrateTo {
+			fmt.Printf("\nUse them by setting this in %s:\n  backend: %s\n", config.GetConfigPath(), migrateTo)
+		}
+		return nil
+	},
+}
+
+func init() {
+	MigrateCmd.Flags().StringVar(&migrateTo, "to", "", "backend to migrate to: 'sqlite' or 'json'")
+	MigrateCmd.MarkFlagRequired("to")
+	RootCmd.AddCommand(MigrateCmd)
+}
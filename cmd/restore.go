@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/telikz/spacdr/internal/app"
+	"github.com/telikz/spacdr/internal/config"
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+var RestoreCmd = &cobra.Command{
+	Use:   "restore <deck> [backup]",
+	Short: "Roll a deck back to a backup",
+	Long:  "List the backups of a deck, newest first, or restore the numbered one. The deck file being replaced is backed up first, and study progress is left as it is",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		database, err := app.UsesDatabase()
+		if err != nil {
+			return err
+		}
+		if database {
+			return fmt.Errorf("backups are only kept of deck files, not with the %s backend", config.BackendSQLite)
+		}
+
+		fullPath := config.GetDeckPath(args[0])
+		store := app.NewBackupStore()
+		backups, err := store.List(fullPath)
+		if err != nil {
+			return fmt.Errorf("error listing backups of %s: %w", args[0], err)
+		}
+		if len(backups) == 0 {
+			fmt.Printf("No backups of %s\n", args[0])
+			return nil
+		}
+
+		if len(args) == 1 {
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "#\tTaken\tCards")
+			for i, backup := range backups {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", i+1, backup.Time.Local().Format("2006-01-02 15:04:05"), countEntries(backup.Path))
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			fmt.Printf("\nRestore one with: spacdr restore %s <#>\n", args[0])
+			return nil
+		}
+
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 || n > len(backups) {
+			return fmt.Errorf("no backup %q of %s, pick one from 1 to %d", args[1], args[0], len(backups))
+		}
+		backup := backups[n-1]
+
+		lock, err := app.LockDeck(fullPath)
+		if err != nil {
+			return fmt.Errorf("can't change %s: %w", args[0], err)
+		}
+		defer lock.Unlock()
+		if err := store.Restore(fullPath, backup); err != nil {
+			return fmt.Errorf("error restoring %s: %w", args[0], err)
+		}
+		fmt.Printf("✓ Restored %s to the backup taken %s\n", args[0], backup.Time.Local().Format("2006-01-02 15:04:05"))
+		return nil
+	},
+}
+
+// countEntries returns the number of cards in a deck file as written, or why
+// they can't be counted.
+func countEntries(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "unreadable"
+	}
+	var deck domain.Deck
+	if err := json.Unmarshal(data, &deck); err != nil {
+		return "invalid"
+	}
+	return strconv.Itoa(len(deck.Cards))
+}
+
+func init() {
+	RootCmd.AddCommand(RestoreCmd)
+}
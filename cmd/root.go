@@ -3,11 +3,18 @@ package cmd
 import (
 	"github.com/telikz/spacdr/internal/app"
 	"github.com/telikz/spacdr/internal/config"
+	"github.com/telikz/spacdr/internal/domain"
 
 	"github.com/spf13/cobra"
 )
 
-var deckPath string
+var (
+	deckPath    string
+	studyAll    bool
+	studyMode   string
+	studyTags   []string
+	excludeTags []string
+)
 
 var RootCmd = &cobra.Command{
 	Use:   "spacdr",
@@ -17,10 +24,18 @@ var RootCmd = &cobra.Command{
 		return config.InitializeConfig()
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return app.StartStudySession(deckPath)
+		return app.StartStudySession(deckPath, app.StudyOptions{
+			All:  studyAll,
+			Mode: studyMode,
+			Tags: domain.TagFilter{Include: studyTags, Exclude: excludeTags},
+		})
 	},
 }
 
 func init() {
 	RootCmd.Flags().StringVar(&deckPath, "deck", "", "path to deck file (relative to .spacdr, e.g. 'spanish/vocabulary' or 'deck.json'). If empty, an interactive menu will be shown")
+	RootCmd.Flags().StringVar(&studyMode, "mode", "", "how to answer cards: 'flip' to reveal and rate yourself, 'type' to type the answer, 'choice' to pick it among four. Defaults to the deck's mode, or flip")
+	RootCmd.Flags().BoolVar(&studyAll, "all", false, "study every card in the deck, not just the ones that are due")
+	RootCmd.Flags().StringSliceVar(&studyTags, "tag", nil, "only study cards with one of these tags (repeatable or comma-separated)")
+	RootCmd.Flags().StringSliceVar(&excludeTags, "exclude-tag", nil, "skip cards with any of these tags (repeatable or comma-separated)")
 }
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/telikz/spacdr/internal/app"
+	"github.com/telikz/spacdr/internal/config"
+	"github.com/telikz/spacdr/internal/service"
+)
+
+var statsJSON bool
+
+type deckStats struct {
+	Deck string `json:"deck"`
+	Name string `json:"name"`
+	*service.Stats
+}
+
+type statsReport struct {
+	Decks []deckStats    `json:"decks"`
+	Total *service.Stats `json:"total"`
+}
+
+var StatsCmd = &cobra.Command{
+	Use:   "stats [deck]",
+	Short: "Show study statistics",
+	Long:  "Show card maturity, retention, review workload and a due forecast for one deck (e.g. 'spanish/vocabulary') or for all decks",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := app.NewDeckService()
+		if err != nil {
+			return err
+		}
+
+		var refs []string
+		if len(args) == 1 {
+			refs = []string{args[0]}
+		} else {
+			refs, err = allDeckRefs()
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		report := statsReport{Decks: []deckStats{}, Total: &service.Stats{}}
+		for _, ref := range refs {
+			fullPath := config.GetDeckPath(ref)
+			deck, err := svc.LoadDeck(fullPath)
+			if err != nil {
+				return fmt.Errorf("error loading deck from %s: %w", fullPath, err)
+			}
+			stats, err := svc.Stats(deck, now)
+			if err != nil {
+				return fmt.Errorf("deck %s: %w", ref, err)
+			}
+			report.Decks = append(report.Decks, deckStats{Deck: ref, Name: deck.Name, Stats: stats})
+			report.Total.Add(stats)
+		}
+
+		if statsJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		}
+
+		if len(report.Decks) == 0 {
+			fmt.Println("No decks found in .spacdr/")
+			return nil
+		}
+		printStats(report)
+		return nil
+	},
+}
+
+// allDeckRefs returns every deck found in the .spacdr directory, sorted by path.
+func allDeckRefs() ([]string, error) {
+	categoryDecks, err := app.DiscoverDecks()
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []string
+	for _, cd := range categoryDecks {
+		for _, deck := range cd.Decks {
+			refs = append(refs, deck.RelativePath)
+		}
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
+
+func printStats(report statsReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Deck\tNew\tLearning\tMature\tSuspended\tRetention 7d\tRetention 30d\tAvg ease\tReviews/day")
+
+	row := func(name string, s *service.Stats) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\t%.1f\n",
+			name, s.New, s.Learning, s.Mature, s.Suspended,
+			formatRetention(s.Retention7d), formatRetention(s.Retention30d),
+			formatEase(s.AverageEase), s.ReviewsPerDay)
+	}
+	for _, ds := range report.Decks {
+		row(ds.Deck, ds.Stats)
+	}
+	if len(report.Decks) > 1 {
+		row("Total", report.Total)
+	}
+	w.Flush()
+
+	fmt.Println()
+	fmt.Printf("Due in the next %d days:\n", service.ForecastDays)
+	printForecast(report.Total.Forecast)
+}
+
+func printForecast(forecast []int) {
+	peak := 0
+	for _, n := range forecast {
+		peak = max(peak, n)
+	}
+
+	today := time.Now()
+	for i, n := range forecast {
+		bar := ""
+		if peak > 0 {
+			bar = strings.Repeat("█", (n*30+peak-1)/peak)
+		}
+		label := today.AddDate(0, 0, i).Format("Mon Jan 02")
+		if i == 0 {
+			label = "Today"
+		}
+		fmt.Println(strings.TrimRight(fmt.Sprintf("  %-10s %4d %s", label, n, bar), " "))
+	}
+}
+
+func formatRetention(r service.Retention) string {
+	if r.Reviews == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%% (%d)", r.Rate*100, r.Reviews)
+}
+
+func formatEase(ease float64) string {
+	if ease == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", ease)
+}
+
+func init() {
+	StatsCmd.Flags().BoolVar(&statsJSON, "json", false, "print statistics as JSON")
+	RootCmd.AddCommand(StatsCmd)
+}
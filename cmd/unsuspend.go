@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/telikz/spacdr/internal/app"
+	"github.com/telikz/spacdr/internal/config"
+)
+
+var UnsuspendCmd = &cobra.Command{
+	Use:   "unsuspend <deck> [card-id...]",
+	Short: "Put suspended cards back into rotation",
+	Long:  "Unsuspend the given cards of a deck, or every suspended card in it if no card IDs are given",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := app.NewDeckService()
+		if err != nil {
+			return err
+		}
+
+		fullPath := config.GetDeckPath(args[0])
+		lock, err := app.LockDeck(fullPath)
+		if err != nil {
+			return fmt.Errorf("can't change %s: %w", args[0], err)
+		}
+		defer lock.Unlock()
+
+		deck, err := svc.LoadDeck(fullPath)
+		if err != nil {
+			return fmt.Errorf("error loading deck from %s: %w", fullPath, err)
+		}
+
+		ids := args[1:]
+		if len(ids) == 0 {
+			for _, card := range deck.Cards {
+				if card.Suspended {
+					ids = append(ids, card.ID)
+				}
+			}
+		}
+		for _, id := range ids {
+			if err := svc.UnsuspendCard(deck, id); err != nil {
+				return err
+			}
+		}
+
+		if err := svc.SaveDeck(fullPath, deck); err != nil {
+			return fmt.Errorf("error saving deck: %w", err)
+		}
+		fmt.Printf("✓ Unsuspended %d card(s) in %s\n", len(ids), args[0])
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(UnsuspendCmd)
+}
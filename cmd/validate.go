@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/telikz/spacdr/internal/app"
+	"github.com/telikz/spacdr/internal/config"
+	"github.com/telikz/spacdr/internal/repo"
+	"github.com/telikz/spacdr/internal/service"
+	"github.com/telikz/spacdr/schema"
+)
+
+var validatePrintSchema bool
+
+var ValidateCmd = &cobra.Command{
+	Use:   "validate [deck]",
+	Short: "Check decks for mistakes",
+	Long:  "Check a deck, or every deck, against the deck format, reporting each problem with its line and card. The deck can also be a path to a deck file that isn't added yet. Decks kept in the database have no file, so their content is checked as it would be written to one and problems are reported by card only",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if validatePrintSchema {
+			_, err := os.Stdout.Write(schema.Deck)
+			return err
+		}
+
+		database, err := app.UsesDatabase()
+		if err != nil {
+			return err
+		}
+
+		// A path to a deck file is checked as it is, even with decks kept in
+		// the database, so files can be checked before they are added.
+		var paths []string
+		if len(args) == 1 && isFile(args[0]) {
+			paths, database = []string{args[0]}, false
+		} else if len(args) == 1 {
+			paths = []string{config.GetDeckPath(args[0])}
+		} else {
+			refs, err := allDeckRefs()
+			if err != nil {
+				return err
+			}
+			for _, ref := range refs {
+				paths = append(paths, config.GetDeckPath(ref))
+			}
+		}
+
+		var svc service.DeckService
+		if database {
+			if svc, err = app.NewDeckService(); err != nil {
+				return err
+			}
+		}
+
+		invalid := 0
+		for _, path := range paths {
+			var problems []repo.Problem
+			if database {
+				deck, err := svc.LoadDeck(path)
+				if err != nil {
+					return fmt.Errorf("error loading %s: %w", path, err)
+				}
+				problems = repo.ValidateDeckContent(deck)
+			} else if problems, err = repo.ValidateDeckFile(path); err != nil {
+				return fmt.Errorf("error reading %s: %w", path, err)
+			}
+			printProblems(path, problems)
+			if repo.HasErrors(problems) {
+				invalid++
+			}
+		}
+
+		if invalid > 0 {
+			return fmt.Errorf("%d of %d deck(s) have errors", invalid, len(paths))
+		}
+		fmt.Printf("✓ No errors in %d deck(s)\n", len(paths))
+		return nil
+	},
+}
+
+// isFile reports whether path is an existing file.
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// printProblems lists the problems found in the deck file at path, one per
+// line, prefixed by where they are.
+func printProblems(path string, problems []repo.Problem) {
+	for _, p := range problems {
+		where := path
+		if p.Line > 0 {
+			where = fmt.Sprintf("%s:%d", path, p.Line)
+		}
+		if p.Card != "" {
+			where += fmt.Sprintf(": card %s", p.Card)
+		}
+		kind := ""
+		if p.Warning {
+			kind = "warning: "
+		}
+		fmt.Printf("%s: %s%s\n", where, kind, p.Message)
+	}
+}
+
+func init() {
+	ValidateCmd.Flags().BoolVar(&validatePrintSchema, "schema", false, "print the JSON Schema of the deck format instead")
+	RootCmd.AddCommand(ValidateCmd)
+}
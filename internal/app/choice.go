@@ -0,0 +1,91 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/service"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// updateChoice handles the number keys and enter in choice mode. It reports
+// false for keys it leaves to the usual handling.
+func (m *UIModel) updateChoice(msg tea.KeyMsg) bool {
+	if m.choice == nil {
+		return false
+	}
+	key := msg.String()
+	switch {
+	case key == "enter":
+		if m.picked >= 0 {
+			m.advance()
+		}
+		return true
+	case len(key) == 1 && key[0] >= '1' && key[0] <= '9':
+		index := int(key[0] - '1')
+		if m.picked < 0 && index < len(m.choice.Options) {
+			m.pickChoice(index)
+		}
+		return true
+	}
+	return false
+}
+
+// pickChoice rates the current card by whether the option at index is the
+// right one, and reveals the answer.
+func (m *UIModel) pickChoice(index int) {
+	if !m.record(m.choice.Score(index)) {
+		return
+	}
+	m.picked = index
+	m.flipped = true
+}
+
+// prepareChoice builds the options for the current card in choice mode.
+func (m *UIModel) prepareChoice() {
+	m.choice = nil
+	m.picked = -1
+	if m.mode != domain.ModeChoice || m.currentCard() == nil {
+		return
+	}
+	choice, err := service.BuildChoice(m.deck, m.queue.at(m.current), m.rng)
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.choice = &choice
+}
+
+func (m *UIModel) choiceView(width int) string {
+	if m.choice == nil {
+		return ""
+	}
+	optionStyle := lipgloss.NewStyle().Width(width).Align(lipgloss.Left)
+	correctStyle := optionStyle.Foreground(lipgloss.Color("42")).Bold(true)
+	wrongStyle := optionStyle.Foreground(lipgloss.Color("196")).Strikethrough(true)
+
+	lines := make([]string, 0, len(m.choice.Options))
+	for i, option := range m.choice.Options {
+		line := fmt.Sprintf("[%d] %s", i+1, option)
+		switch {
+		case m.picked >= 0 && i == m.choice.Correct:
+			lines = append(lines, correctStyle.Render("✓ "+line))
+		case i == m.picked:
+			lines = append(lines, wrongStyle.Render("✗ "+line))
+		case m.picked >= 0:
+			lines = append(lines, optionStyle.Render("  "+line))
+		default:
+			lines = append(lines, optionStyle.Render(line))
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func (m *UIModel) choiceHelp() string {
+	if m.picked < 0 {
+		return fmt.Sprintf("Pick an answer: [1-%d]  |  [U] undo  |  [J/K] navigate  |  [S] suspend  |  [-] bury  |  [B] back", len(m.choice.Options))
+	}
+	return "[Enter] next card  |  [U] undo  |  [J/K] navigate  |  [B] back"
+}
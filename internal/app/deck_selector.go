@@ -12,6 +12,7 @@ type DeckItem struct {
 	name       string
 	category   string
 	path       string
+	tags       []string
 	isCategory bool
 }
 
@@ -53,6 +54,7 @@ func (m *DeckSelectorModel) buildItems() {
 				name:     deck.Name,
 				category: cd.Category,
 				path:     deck.RelativePath,
+				tags:     deck.Tags,
 			})
 		}
 	}
@@ -68,9 +70,8 @@ func (m *DeckSelectorModel) updateFilter() {
 			m.filteredIdx = append(m.filteredIdx, i)
 		}
 	} else {
-		query := strings.ToLower(m.searchQuery)
 		for i, item := range m.allItems {
-			if strings.Contains(strings.ToLower(item.name), query) {
+			if m.matches(item) {
 				m.filteredIdx = append(m.filteredIdx, i)
 			}
 		}
@@ -87,6 +88,29 @@ func (m *DeckSelectorModel) updateFilter() {
 	m.ensureVisible()
 }
 
+// matches reports whether the item's name or one of its tags contains the
+// search query. A query starting with # only searches tags.
+func (m *DeckSelectorModel) matches(item DeckItem) bool {
+	query := strings.ToLower(m.searchQuery)
+	tagsOnly := strings.HasPrefix(query, "#")
+	if tagsOnly {
+		query = query[1:]
+	} else if strings.Contains(strings.ToLower(item.name), query) {
+		return true
+	}
+	return len(m.matchingTags(item, query)) > 0
+}
+
+func (m *DeckSelectorModel) matchingTags(item DeckItem, query string) []string {
+	var tags []string
+	for _, tag := range item.tags {
+		if strings.Contains(strings.ToLower(tag), query) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 func (m *DeckSelectorModel) Init() tea.Cmd {
 	return nil
 }
@@ -242,6 +266,12 @@ func (m *DeckSelectorModel) View() string {
 				}
 
 				deckName := item.name
+				if m.searchQuery != "" {
+					query := strings.TrimPrefix(strings.ToLower(m.searchQuery), "#")
+					for _, tag := range m.matchingTags(item, query) {
+						deckName += " #" + tag
+					}
+				}
 				maxLen := listWidth - len(prefix) - 4
 				if len(deckName) > maxLen && maxLen > 3 {
 					deckName = deckName[:maxLen-3] + "..."
@@ -272,7 +302,7 @@ func (m *DeckSelectorModel) View() string {
 
 	var help string
 	if m.searchMode {
-		help = helpStyle.Render("Type to search • Esc to exit search")
+		help = helpStyle.Render("Type to search names and tags, #tag for tags only • Esc to exit search")
 	} else {
 		help = helpStyle.Render("↑↓ JK | L select | / search  | Q quit")
 	}
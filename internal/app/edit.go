@@ -0,0 +1,191 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/telikz/spacdr/internal/domain"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"go.yaml.in/yaml/v3"
+)
+
+const editHeader = `# Edit the card, then save and close the editor. Its scheduling is kept.
+# Delete everything to delete the card.
+`
+
+// cardFile is the part of a deck entry that can be edited.
+type cardFile struct {
+	Front  string            `yaml:"front,omitempty"`
+	Back   string            `yaml:"back,omitempty"`
+	Cloze  string            `yaml:"cloze,omitempty"`
+	Type   string            `yaml:"type,omitempty"`
+	Fields map[string]string `yaml:"fields,omitempty"`
+	Tags   []string          `yaml:"tags,omitempty"`
+}
+
+type editFinishedMsg struct {
+	path   string
+	cardID string
+	err    error
+}
+
+// editCard opens the entry of the current card in the user's editor. If the
+// last edit was invalid, that file is opened again instead.
+func (m *UIModel) editCard() tea.Cmd {
+	card := m.currentCard()
+	if card == nil || !m.writable() {
+		return nil
+	}
+
+	path := m.pendingEdit
+	if path == "" || m.pendingEditCard != card.ID {
+		var err error
+		path, err = writeCardFile(m.deck, card.ID)
+		if err != nil {
+			m.err = err.Error()
+			return nil
+		}
+	}
+	m.pendingEdit, m.pendingEditCard = "", ""
+
+	id := card.ID
+	return tea.ExecProcess(editorCommand(path), func(err error) tea.Msg {
+		return editFinishedMsg{path: path, cardID: id, err: err}
+	})
+}
+
+func writeCardFile(deck *domain.Deck, cardID string) (string, error) {
+	entry, _ := deck.EntryOf(cardID)
+	data, err := yaml.Marshal(cardFile{
+		Front:  entry.Front,
+		Back:   entry.Back,
+		Cloze:  entry.Cloze,
+		Type:   entry.Type,
+		Fields: entry.Fields,
+		Tags:   entry.Tags,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error preparing card for editing: %w", err)
+	}
+
+	f, err := os.CreateTemp("", "spacdr-card-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("error preparing card for editing: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(editHeader + string(data)); err != nil {
+		return "", fmt.Errorf("error preparing card for editing: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// editorCommand runs $VISUAL or $EDITOR on path, falling back to vi. The
+// variable may include arguments, e.g. "code --wait".
+func editorCommand(path string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	return exec.Command(args[0], append(args[1:], path)...)
+}
+
+// finishEdit applies the edited file to the deck. An empty file asks whether
+// to delete the card; an invalid one is kept so pressing e again fixes it.
+func (m *UIModel) finishEdit(msg editFinishedMsg) {
+	if msg.err != nil {
+		os.Remove(msg.path)
+		m.err = fmt.Sprintf("error running editor: %v", msg.err)
+		return
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.err = fmt.Sprintf("error reading edited card: %v", err)
+		return
+	}
+
+	if isBlank(data) {
+		os.Remove(msg.path)
+		m.confirmDelete = msg.cardID
+		return
+	}
+
+	entry, err := parseCardFile(data)
+	if err == nil {
+		err = m.svc.EditCard(m.deck, msg.cardID, entry)
+	}
+	if err != nil {
+		m.pendingEdit, m.pendingEditCard = msg.path, msg.cardID
+		m.err = err.Error() + " (press e to fix it)"
+		return
+	}
+
+	os.Remove(msg.path)
+	m.saveEdit()
+}
+
+func parseCardFile(data []byte) (domain.Card, error) {
+	var file cardFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return domain.Card{}, fmt.Errorf("invalid card: %w", err)
+	}
+	return domain.Card{
+		Front:  strings.TrimSpace(file.Front),
+		Back:   strings.TrimSpace(file.Back),
+		Cloze:  strings.TrimSpace(file.Cloze),
+		Type:   file.Type,
+		Fields: file.Fields,
+		Tags:   file.Tags,
+	}, nil
+}
+
+// isBlank reports whether data holds nothing but comments and whitespace.
+func isBlank(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// updateConfirmDelete handles the answer to whether to delete the card whose
+// content was cleared in the editor.
+func (m *UIModel) updateConfirmDelete(msg tea.KeyMsg) {
+	id := m.confirmDelete
+	m.confirmDelete = ""
+	if msg.String() != "y" {
+		return
+	}
+	if err := m.svc.DeleteCard(m.deck, id); err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.saveEdit()
+}
+
+// saveEdit saves the deck after its content changed and drops cards that no
+// longer exist from the queue, and their ratings from the undo history.
+func (m *UIModel) saveEdit() {
+	m.err = ""
+	if err := m.svc.SaveDeck(m.filePath, m.deck); err != nil {
+		m.err = err.Error()
+	}
+
+	exists := func(id string) bool { return m.deck.CardByID(id) != nil }
+	m.history = slices.DeleteFunc(m.history, func(r rating) bool { return !exists(r.cardID) })
+	m.showCard(m.queue.keep(m.current, exists))
+}
@@ -0,0 +1,66 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/repo"
+	"github.com/telikz/spacdr/internal/service"
+)
+
+func createEditModel(t *testing.T) *UIModel {
+	t.Helper()
+	deck := createQueueDeck(time.Now())
+	filePath := filepath.Join(t.TempDir(), "deck.json")
+	deck.Path = filePath
+	svc := service.NewDeckService(repo.NewFileDeckRepository())
+	return NewUIModel(deck, []string{"a", "b", "c"}, filePath, svc, "flip", service.LearningSteps{})
+}
+
+// deleteCard deletes the card the way confirming an emptied edit does.
+func deleteCard(t *testing.T, m *UIModel, id string) {
+	t.Helper()
+	if err := m.svc.DeleteCard(m.deck, id); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m.saveEdit()
+}
+
+func TestUndoAfterEarlierCardDeleted(t *testing.T) {
+	m := createEditModel(t)
+	m.rate(4)
+	m.rate(4)
+	deleteCard(t, m, "a")
+
+	m.undo()
+	if m.err != "" {
+		t.Fatalf("Unexpected error: %s", m.err)
+	}
+	if card := m.currentCard(); card == nil || card.ID != "b" {
+		t.Errorf("Expected undo to go back to b, got %+v", card)
+	}
+	if !m.queue.pending("b") {
+		t.Error("Expected b to be pending again")
+	}
+}
+
+func TestUndoSkipsDeletedCards(t *testing.T) {
+	m := createEditModel(t)
+	m.rate(4)
+	m.rate(4)
+	m.showCard(1)
+	deleteCard(t, m, "b")
+
+	m.undo()
+	if m.err != "" {
+		t.Fatalf("Expected the rating of a to be undone, got %s", m.err)
+	}
+	if card := m.currentCard(); card == nil || card.ID != "a" {
+		t.Errorf("Expected undo to go back to a, got %+v", card)
+	}
+	m.undo()
+	if m.err != "nothing to undo" {
+		t.Errorf("Expected nothing left to undo, got %q", m.err)
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// advance moves on to the next card of the session, waits for the next
+// learning card if every card left is learning, and finishes the session when
+// none is left.
+func (m *UIModel) advance() {
+	if index, ok := m.queue.next(m.current, time.Now()); ok {
+		m.waiting = false
+		m.showCard(index)
+		return
+	}
+	if _, _, ok := m.queue.nextLearning(); ok {
+		m.waiting = true
+		return
+	}
+	m.waiting = false
+	m.finish()
+}
+
+// updateWaiting handles keys while waiting for the next learning card.
+func (m *UIModel) updateWaiting(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		if index, _, ok := m.queue.nextLearning(); ok {
+			m.waiting = false
+			m.showCard(index)
+		}
+	case "b":
+		m.goBack = true
+		return m, tea.Quit
+	case "q", "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	case "u", "ctrl+z":
+		m.undo()
+	}
+	return m, nil
+}
+
+func (m *UIModel) waitingView() string {
+	_, due, _ := m.queue.nextLearning()
+	_, _, learning := m.queue.remaining()
+	wait := max(time.Until(due), 0).Round(time.Second)
+
+	messageStyle := lipgloss.NewStyle().
+		Bold(true).
+		Align(lipgloss.Center).
+		Width(m.width)
+	helpStyle := lipgloss.NewStyle().
+		Italic(true).
+		Align(lipgloss.Center).
+		Width(m.width)
+
+	lines := []string{
+		messageStyle.Render(fmt.Sprintf("%d cards still learning. The next one is back in %d:%02d.", learning, int(wait.Minutes()), int(wait.Seconds())%60)),
+	}
+	if m.err != "" {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Align(lipgloss.Center).
+			Width(m.width)
+		lines = append(lines, errorStyle.Render("✗ "+m.err))
+	}
+	message := lipgloss.JoinVertical(lipgloss.Top, lines...)
+	help := helpStyle.Render("[Enter] study it now  |  [U] undo  |  [B] back  |  [Q] quit")
+
+	verticalSpace := max(m.height-lipgloss.Height(message)-lipgloss.Height(help), 0)
+	return lipgloss.JoinVertical(lipgloss.Top,
+		strings.Repeat("\n", verticalSpace/2),
+		message,
+		strings.Repeat("\n", verticalSpace-verticalSpace/2),
+		help,
+	)
+}
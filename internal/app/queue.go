@@ -0,0 +1,181 @@
+package app
+
+import (
+	"slices"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/service"
+)
+
+// sessionQueue holds the cards of a study session, given by ID in the order
+// they should be studied, and keeps track of which ones are done. Cards going
+// through learning steps come back once their step is due, so a card is done
+// when it was rated and isn't learning, or was suspended or buried.
+type sessionQueue struct {
+	ids   []string
+	steps service.LearningSteps
+
+	// isNew marks the cards that had never been studied when the session
+	// started, rated the ones rated since, and shelved the ones suspended or
+	// buried during the session.
+	isNew   map[string]bool
+	rated   map[string]bool
+	shelved map[string]bool
+
+	// learning holds where the cards going through learning steps are.
+	learning map[string]service.LearningStep
+}
+
+func newSessionQueue(deck *domain.Deck, ids []string, steps service.LearningSteps) *sessionQueue {
+	isNew := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if card := deck.CardByID(id); card != nil && card.LastReview.IsZero() {
+			isNew[id] = true
+		}
+	}
+	return &sessionQueue{
+		ids:      ids,
+		steps:    steps,
+		isNew:    isNew,
+		rated:    make(map[string]bool, len(ids)),
+		shelved:  make(map[string]bool),
+		learning: make(map[string]service.LearningStep),
+	}
+}
+
+func (q *sessionQueue) len() int {
+	return len(q.ids)
+}
+
+func (q *sessionQueue) at(index int) string {
+	return q.ids[index]
+}
+
+// indexOf returns where the card is in the queue.
+func (q *sessionQueue) indexOf(id string) (int, bool) {
+	index := slices.Index(q.ids, id)
+	return index, index >= 0
+}
+
+// pending reports whether the card hasn't been rated, suspended or buried yet.
+func (q *sessionQueue) pending(id string) bool {
+	return !q.rated[id] && !q.shelved[id]
+}
+
+// next returns the index of the card to show after the one at current: the
+// learning card that has been due the longest, or else the next pending card,
+// wrapping around to the ones skipped earlier. It reports false when no card
+// is ready at now.
+func (q *sessionQueue) next(current int, now time.Time) (int, bool) {
+	var due string
+	for id, step := range q.learning {
+		if !step.Due.After(now) && (due == "" || step.Due.Before(q.learning[due].Due)) {
+			due = id
+		}
+	}
+	if due != "" {
+		return slices.Index(q.ids, due), true
+	}
+
+	for i := 1; i <= len(q.ids); i++ {
+		index := (current + i) % len(q.ids)
+		if q.pending(q.ids[index]) {
+			return index, true
+		}
+	}
+	return 0, false
+}
+
+// nextLearning returns the index of the learning card that comes back first
+// and when it does. It reports false when no card is learning.
+func (q *sessionQueue) nextLearning() (int, time.Time, bool) {
+	var first string
+	for id, step := range q.learning {
+		if first == "" || step.Due.Before(q.learning[first].Due) {
+			first = id
+		}
+	}
+	if first == "" {
+		return 0, time.Time{}, false
+	}
+	return slices.Index(q.ids, first), q.learning[first].Due, true
+}
+
+// rate records a rating of the card, whose state before it was before, and
+// moves it through its learning steps.
+func (q *sessionQueue) rate(id string, before domain.CardState, score int, now time.Time) {
+	q.rated[id] = true
+	var current *service.LearningStep
+	if step, ok := q.learning[id]; ok {
+		current = &step
+	}
+	q.setLearning(id, q.steps.Next(current, before.LastReview.IsZero(), score, now))
+}
+
+// restore puts a card back the way it was before a rating, for undo.
+func (q *sessionQueue) restore(id string, wasRated bool, step *service.LearningStep) {
+	q.rated[id] = wasRated
+	q.setLearning(id, step)
+}
+
+// learningStep returns where the card is in its learning steps, nil if it
+// isn't learning.
+func (q *sessionQueue) learningStep(id string) *service.LearningStep {
+	if step, ok := q.learning[id]; ok {
+		return &step
+	}
+	return nil
+}
+
+func (q *sessionQueue) setLearning(id string, step *service.LearningStep) {
+	if step == nil {
+		delete(q.learning, id)
+		return
+	}
+	q.learning[id] = *step
+}
+
+// shelve records that the card was taken out of rotation, or put back. A
+// card taken out stops learning.
+func (q *sessionQueue) shelve(id string, out bool) {
+	q.shelved[id] = out
+	if out {
+		delete(q.learning, id)
+	}
+}
+
+// keep drops the cards for which keep reports false, and returns the new
+// index of the card at index, or of the card after it if it was dropped.
+func (q *sessionQueue) keep(index int, keep func(id string) bool) int {
+	ids := q.ids[:0]
+	moved := index
+	for i, id := range q.ids {
+		if keep(id) {
+			ids = append(ids, id)
+			continue
+		}
+		delete(q.learning, id)
+		if i < index {
+			moved--
+		}
+	}
+	q.ids = ids
+	return min(max(moved, 0), max(len(ids)-1, 0))
+}
+
+// remaining counts the queued reviews and new cards not rated yet, and the
+// cards still learning. The queue is built within the daily limits, so the
+// first two are also what's left for today.
+func (q *sessionQueue) remaining() (reviews, newCards, learning int) {
+	for _, id := range q.ids {
+		switch {
+		case !q.pending(id):
+		case q.isNew[id]:
+			newCards++
+		default:
+			reviews++
+		}
+	}
+	return reviews, newCards, len(q.learning)
+}
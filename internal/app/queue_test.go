@@ -0,0 +1,131 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/service"
+)
+
+func createQueueDeck(now time.Time) *domain.Deck {
+	studied := domain.Card{LastReview: now.Add(-48 * time.Hour), Interval: 2}
+	review := func(id string) domain.Card {
+		card := studied
+		card.ID, card.Front, card.Back = id, "Q "+id, "A "+id
+		return card
+	}
+	return &domain.Deck{Cards: []domain.Card{
+		{ID: "new", Front: "Q new", Back: "A new"},
+		review("a"),
+		review("b"),
+		review("c"),
+	}}
+}
+
+func TestSessionQueueLearningCardComesBackWhenDue(t *testing.T) {
+	now := time.Now()
+	deck := createQueueDeck(now)
+	q := newSessionQueue(deck, []string{"new", "a", "b"}, service.DefaultLearningSteps)
+
+	q.rate("new", deck.CardByID("new").State(), 3, now)
+	if _, _, learning := q.remaining(); learning != 1 {
+		t.Fatalf("Expected the new card to be learning, got %d learning", learning)
+	}
+
+	// Not due yet: the queue moves on to the pending cards.
+	if index, ok := q.next(0, now); !ok || q.at(index) != "a" {
+		t.Errorf("Expected a next, got %d, %v", index, ok)
+	}
+	index, due, ok := q.nextLearning()
+	if !ok || q.at(index) != "new" || !due.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("Expected new back in 10m, got %d at %v", index, due)
+	}
+
+	// Once due, it comes before the pending cards.
+	if index, ok := q.next(1, now.Add(10*time.Minute)); !ok || q.at(index) != "new" {
+		t.Errorf("Expected new to come back when due, got %d, %v", index, ok)
+	}
+
+	// Passing the last step lets it leave the session.
+	q.rate("new", deck.CardByID("new").State(), 3, now.Add(10*time.Minute))
+	if q.learningStep("new") != nil {
+		t.Error("Expected new to graduate after its last step")
+	}
+}
+
+func TestSessionQueueWrapsAroundToSkippedCards(t *testing.T) {
+	now := time.Now()
+	deck := createQueueDeck(now)
+	q := newSessionQueue(deck, []string{"a", "b", "c"}, service.DefaultLearningSteps)
+
+	// a was skipped, b and c rated: the queue wraps around to a.
+	q.rate("b", deck.CardByID("b").State(), 4, now)
+	q.rate("c", deck.CardByID("c").State(), 4, now)
+	if index, ok := q.next(2, now); !ok || index != 0 {
+		t.Errorf("Expected to wrap around to a, got %d, %v", index, ok)
+	}
+
+	q.rate("a", deck.CardByID("a").State(), 4, now)
+	if _, ok := q.next(0, now); ok {
+		t.Error("Expected no card left once all were rated")
+	}
+	if reviews, newCards, learning := q.remaining(); reviews+newCards+learning != 0 {
+		t.Errorf("Expected nothing remaining, got %d reviews, %d new, %d learning", reviews, newCards, learning)
+	}
+}
+
+func TestSessionQueueRestoreAfterUndo(t *testing.T) {
+	now := time.Now()
+	deck := createQueueDeck(now)
+	q := newSessionQueue(deck, []string{"a", "b"}, service.DefaultLearningSteps)
+
+	// A failed review starts relearning; undoing it puts the card back as
+	// pending and not learning.
+	wasRated, step := q.rated["a"], q.learningStep("a")
+	q.rate("a", deck.CardByID("a").State(), 1, now)
+	if q.learningStep("a") == nil || !q.learningStep("a").Relearning {
+		t.Fatal("Expected a to be relearning after a failure")
+	}
+	q.restore("a", wasRated, step)
+	if !q.pending("a") || q.learningStep("a") != nil {
+		t.Errorf("Expected a to be pending again, got rated %v, step %+v", q.rated["a"], q.learningStep("a"))
+	}
+	if reviews, _, learning := q.remaining(); reviews != 2 || learning != 0 {
+		t.Errorf("Expected 2 reviews and nothing learning, got %d and %d", reviews, learning)
+	}
+
+	// Undoing a repeat puts the card back at its earlier step.
+	q.rate("a", deck.CardByID("a").State(), 1, now)
+	wasRated, step = q.rated["a"], q.learningStep("a")
+	q.rate("a", deck.CardByID("a").State(), 4, now.Add(10*time.Minute))
+	q.restore("a", wasRated, step)
+	if got := q.learningStep("a"); got == nil || *got != *step {
+		t.Errorf("Expected a back at %+v, got %+v", step, got)
+	}
+}
+
+func TestSessionQueueKeepMovesIndex(t *testing.T) {
+	now := time.Now()
+	deck := createQueueDeck(now)
+	q := newSessionQueue(deck, []string{"new", "a", "b", "c"}, service.DefaultLearningSteps)
+	q.rate("new", deck.CardByID("new").State(), 1, now)
+
+	// Deleting cards before the current one moves it back, and deleted
+	// cards stop learning.
+	index := q.keep(2, func(id string) bool { return id != "new" && id != "a" })
+	if index != 0 || q.at(index) != "b" || q.len() != 2 {
+		t.Errorf("Expected b at 0 of 2, got %d of %d", index, q.len())
+	}
+	if _, _, learning := q.remaining(); learning != 0 {
+		t.Errorf("Expected the deleted card to stop learning, got %d learning", learning)
+	}
+
+	// Deleting the current card moves on to the one after it, or the last.
+	if index := q.keep(0, func(id string) bool { return id != "b" }); index != 0 || q.at(index) != "c" {
+		t.Errorf("Expected c after deleting b, got %d", index)
+	}
+	if index := q.keep(0, func(id string) bool { return false }); index != 0 || q.len() != 0 {
+		t.Errorf("Expected an empty queue, got %d cards, index %d", q.len(), index)
+	}
+}
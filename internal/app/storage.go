@@ -0,0 +1,165 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/telikz/spacdr/internal/config"
+	"github.com/telikz/spacdr/internal/repo"
+)
+
+// sqliteStore is the database of the sqlite backend, opened on first use and
+// shared by everything in the process. It is left for the process exit to
+// close.
+var sqliteStore *repo.SQLiteStore
+
+func openStore() (*repo.SQLiteStore, error) {
+	if sqliteStore == nil {
+		store, err := repo.OpenSQLiteStore(config.GetDatabasePath(), config.GetSpacdrDir())
+		if err != nil {
+			return nil, err
+		}
+		sqliteStore = store
+	}
+	return sqliteStore, nil
+}
+
+// repositories returns where decks and review logs are kept for the configured
+// backend: deck files with progress and review logs in the spacdr state
+// directory and backups before they change, or the spacdr database.
+func repositories() (repo.DeckRepository, repo.ReviewLogRepository, error) {
+	database, err := UsesDatabase()
+	if err != nil {
+		return nil, nil, err
+	}
+	if database {
+		store, err := openStore()
+		if err != nil {
+			return nil, nil, err
+		}
+		return repo.NewSQLiteDeckRepository(store), repo.NewSQLiteReviewLogRepository(store), nil
+	}
+	return repo.NewFileDeckRepository(repoOptions()...), repo.NewFileReviewLogRepository(repoOptions()...), nil
+}
+
+// UsesDatabase reports whether decks are kept in the spacdr database rather
+// than in deck files.
+func UsesDatabase() (bool, error) {
+	backend, err := config.GetBackend()
+	if err != nil {
+		return false, fmt.Errorf("invalid config: %w", err)
+	}
+	return backend == config.BackendSQLite, nil
+}
+
+// DiscoverDecks returns the decks of the configured backend, grouped by
+// category.
+func DiscoverDecks() ([]config.CategoryDecks, error) {
+	database, err := UsesDatabase()
+	if err != nil {
+		return nil, err
+	}
+	if !database {
+		return config.DiscoverDecks()
+	}
+	store, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := store.DeckPaths()
+	if err != nil {
+		return nil, err
+	}
+	return config.GroupDecks(paths), nil
+}
+
+// AddDeckFile copies the deck file at sourcePath to deckPath, backing up the
+// deck file it replaces.
+func AddDeckFile(sourcePath, deckPath string) error {
+	data, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return err
+	}
+	return repo.ReplaceDeckFile(deckPath, data, NewBackupStore())
+}
+
+// ImportDeck stores the deck file at sourcePath in the database as the deck at
+// deckPath. If the database already has that deck, its cards keep their
+// progress. The source file is only read, even if it is in an older format.
+func ImportDeck(sourcePath, deckPath string) error {
+	store, err := openStore()
+	if err != nil {
+		return err
+	}
+	deck, err := repo.ReadDeckFile(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	decks := repo.NewSQLiteDeckRepository(store)
+	existing, err := decks.Load(deckPath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if existing != nil {
+		for i := range deck.Cards {
+			card := &deck.Cards[i]
+			if old := existing.CardByID(card.ID); old != nil && card.State().IsZero() {
+				card.SetState(old.State())
+			}
+		}
+	}
+	return decks.Save(deckPath, deck)
+}
+
+// MigrateToSQLite copies every deck file, with its progress and review log,
+// into the spacdr database, and returns their paths.
+func MigrateToSQLite() ([]string, error) {
+	paths, err := config.DeckFiles()
+	if err != nil {
+		return nil, err
+	}
+	store, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	return paths, migrateDecks(paths, func(path string) error {
+		return repo.MigrateToSQLite(store, path, repoOptions()...)
+	})
+}
+
+// MigrateToJSON writes every deck in the spacdr database back to deck files,
+// with progress and review logs in the spacdr state directory, and returns
+// their paths.
+func MigrateToJSON() ([]string, error) {
+	store, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := store.DeckPaths()
+	if err != nil {
+		return nil, err
+	}
+	return paths, migrateDecks(paths, func(path string) error {
+		return repo.MigrateToJSON(store, path, repoOptions()...)
+	})
+}
+
+// migrateDecks migrates the decks at paths one by one, each locked so a study
+// session or command can't change it meanwhile.
+func migrateDecks(paths []string, migrate func(path string) error) error {
+	for _, path := range paths {
+		lock, err := LockDeck(path)
+		if err != nil {
+			return fmt.Errorf("can't migrate %s: %w", path, err)
+		}
+		err = migrate(path)
+		lock.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,22 +1,92 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
 	"github.com/telikz/spacdr/internal/config"
+	"github.com/telikz/spacdr/internal/domain"
 	"github.com/telikz/spacdr/internal/repo"
 	"github.com/telikz/spacdr/internal/service"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func StartStudySession(deckPath string) error {
-	repo := repo.NewFileDeckRepository()
-	svc := service.NewDeckService(repo)
+// NewDeckService returns the deck service configured from config.yaml, with
+// decks kept in the configured backend. opts are applied after the configured
+// ones.
+func NewDeckService(opts ...service.Option) (service.DeckService, error) {
+	sched, err := config.GetScheduler()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	deckRepo, reviewLog, err := repositories()
+	if err != nil {
+		return nil, err
+	}
+
+	configured := []service.Option{
+		service.WithScheduler(sched),
+		service.WithReviewLog(reviewLog),
+		service.WithNewCardsPerDay(config.GetNewCardsPerDay()),
+		service.WithReviewsPerDay(config.GetReviewsPerDay()),
+		service.WithLeechThreshold(config.GetLeechThreshold()),
+		service.WithLeechSuspend(config.GetLeechSuspend()),
+	}
+	return service.NewDeckService(deckRepo, append(configured, opts...)...), nil
+}
+
+// NewBackupStore returns the store holding the backups of deck files.
+func NewBackupStore() repo.BackupStore {
+	return repo.NewFileBackupStore(repoOptions()...)
+}
+
+// LockDeck locks the deck against changes by other spacdr processes, failing
+// with repo.ErrLocked if one of them holds it.
+func LockDeck(deckPath string) (*repo.DeckLock, error) {
+	return repo.NewFileLocker(repoOptions()...).Lock(deckPath)
+}
 
+// repoOptions keeps per-user state and backups under the spacdr directory.
+func repoOptions() []repo.Option {
+	return []repo.Option{
+		repo.WithStateDir(config.GetSpacdrDir(), config.GetStateDir()),
+		repo.WithBackups(config.GetSpacdrDir(), config.GetBackupDir(), config.GetBackups()),
+	}
+}
+
+type StudyOptions struct {
+	// All studies every card in the deck instead of only the due ones.
+	All bool
+	// Mode overrides the deck's study mode.
+	Mode string
+	// Tags only studies the cards matching the filter.
+	Tags domain.TagFilter
+}
+
+func StartStudySession(deckPath string, opts StudyOptions) error {
+	if opts.Mode != "" && !domain.IsStudyMode(opts.Mode) {
+		return fmt.Errorf("unknown study mode %q (available: %v)", opts.Mode, domain.StudyModes())
+	}
+
+	svc, err := NewDeckService(service.WithTagFilter(opts.Tags))
+	if err != nil {
+		return err
+	}
+	steps, err := config.GetLearningSteps()
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	// more is set when the previous session of the deck asked to study more:
+	// once nothing is due, that studies ahead through every card.
+	more := false
 	for {
 		if deckPath == "" {
-			selectedPath, err := selectDeckInteractively()
+			selectedPath, err := selectDeckInteractively(svc)
 			if err != nil {
 				return err
 			}
@@ -28,19 +98,55 @@ func StartStudySession(deckPath string) error {
 
 		fullPath := config.GetDeckPath(deckPath)
 
+		// A deck studied in another session can still be looked through,
+		// but not changed.
+		var readOnly string
+		lock, err := LockDeck(fullPath)
+		if errors.Is(err, repo.ErrLocked) {
+			readOnly = err.Error()
+		} else if err != nil {
+			return fmt.Errorf("error locking %s: %w", fullPath, err)
+		}
+
 		deck, err := svc.LoadDeck(fullPath)
 		if err != nil {
+			unlock(lock)
 			return fmt.Errorf("error loading deck from %s: %w", fullPath, err)
 		}
 
-		svc.SortCardsByScore(deck)
+		queue := svc.StudyQueue(deck)
+		if !opts.All {
+			queue, err = svc.DueQueue(deck, time.Now())
+			if err != nil {
+				unlock(lock)
+				return fmt.Errorf("error building study queue for %s: %w", fullPath, err)
+			}
+			if more && len(queue) == 0 {
+				queue = svc.StudyQueue(deck)
+			}
+		}
+
+		mode := opts.Mode
+		if mode == "" {
+			mode = deck.Mode
+		}
+		if mode == "" {
+			mode = domain.ModeFlip
+		}
 
-		uiModel := NewUIModel(deck, fullPath, svc)
+		uiModel := NewUIModel(deck, queue, fullPath, svc, mode, steps)
+		uiModel.readOnly = readOnly
 		p := tea.NewProgram(uiModel, tea.WithAltScreen())
-		if _, err := p.Run(); err != nil {
+		_, err = p.Run()
+		unlock(lock)
+		if err != nil {
 			return err
 		}
 
+		more = uiModel.studyMore
+		if more {
+			continue
+		}
 		if !uiModel.goBack {
 			break
 		}
@@ -50,8 +156,16 @@ func StartStudySession(deckPath string) error {
 	return nil
 }
 
-func selectDeckInteractively() (string, error) {
-	categoryDecks, err := config.DiscoverDecks()
+// unlock releases lock if it was taken. The process is done with the deck
+// either way, and exiting releases the lock too, so errors are ignored.
+func unlock(lock *repo.DeckLock) {
+	if lock != nil {
+		lock.Unlock()
+	}
+}
+
+func selectDeckInteractively(svc service.DeckService) (string, error) {
+	categoryDecks, err := DiscoverDecks()
 	if err != nil {
 		return "", err
 	}
@@ -60,6 +174,16 @@ func selectDeckInteractively() (string, error) {
 		return "", fmt.Errorf("no decks found in .spacdr/")
 	}
 
+	// Tags make decks searchable by what's in them. A deck that doesn't load
+	// is still listed, and selecting it reports why.
+	for _, cd := range categoryDecks {
+		for i := range cd.Decks {
+			if deck, err := svc.LoadDeck(cd.Decks[i].FullPath); err == nil {
+				cd.Decks[i].Tags = slices.Sorted(maps.Keys(deck.TagCounts()))
+			}
+		}
+	}
+
 	selector := NewDeckSelectorModel(categoryDecks)
 	p := tea.NewProgram(selector, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// summaryBarWidth is the width of the longest bar of the rating distribution.
+const summaryBarWidth = 20
+
+// finish ends the session and shows what was studied.
+func (m *UIModel) finish() {
+	summary, err := m.svc.SessionSummary(m.deck, m.startedAt, time.Now())
+	if err != nil {
+		m.err = err.Error()
+	}
+	m.summary = &summary
+	m.flipped = false
+	m.input = nil
+	m.answer = nil
+	m.choice = nil
+}
+
+// updateSummary handles keys on the summary screen.
+func (m *UIModel) updateSummary(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "m", "enter":
+		m.studyMore = true
+		return m, tea.Quit
+	case "b":
+		m.goBack = true
+		return m, tea.Quit
+	case "q", "ctrl+c":
+		m.quitting = true
+		return m, tea.Quit
+	case "u", "ctrl+z":
+		m.undo()
+	}
+	return m, nil
+}
+
+func (m *UIModel) summaryView() string {
+	s := m.summary
+	now := time.Now()
+
+	titleStyle := lipgloss.NewStyle().Bold(true)
+	labelStyle := lipgloss.NewStyle().Width(12).Foreground(lipgloss.Color("245"))
+	barStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("63"))
+
+	row := func(label, value string) string {
+		return labelStyle.Render(label) + value
+	}
+
+	lines := []string{
+		titleStyle.Render("Session complete: " + m.deck.Name),
+		"",
+		row("Reviewed", fmt.Sprintf("%d cards, %d ratings in %s", s.Cards, s.Reviewed, s.TimeSpent.Round(time.Second))),
+	}
+	if s.Reviewed > 0 {
+		lines = append(lines,
+			row("Accuracy", fmt.Sprintf("%.0f%% (%d of %d rated 3 or higher)", s.Accuracy*100, s.Passed, s.Reviewed)),
+			row("New", fmt.Sprintf("%d", s.New)),
+			row("Relearned", fmt.Sprintf("%d", s.Relearned)),
+			"",
+		)
+
+		most := 1
+		for _, n := range s.Ratings {
+			most = max(most, n)
+		}
+		for i, n := range s.Ratings {
+			bar := barStyle.Render(strings.Repeat("█", n*summaryBarWidth/most))
+			lines = append(lines, row(fmt.Sprintf("Rated %d", i+1), fmt.Sprintf("%s %d", bar, n)))
+		}
+		lines = append(lines, "")
+	}
+
+	next := "nothing scheduled"
+	more := "[M] study ahead"
+	switch {
+	case s.DueNow > 0:
+		next = fmt.Sprintf("%d cards now", s.DueNow)
+		more = fmt.Sprintf("[M] study %d more", s.DueNow)
+	case !s.NextDue.IsZero():
+		next = fmt.Sprintf("%s (%s)", FormatUntil(s.NextDue.Sub(now)), s.NextDue.Format("Mon 15:04"))
+	}
+	lines = append(lines, row("Next due", next))
+	if m.err != "" {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		lines = append(lines, "", errorStyle.Render("✗ "+m.err))
+	}
+
+	body := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		Padding(1, 2).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+	body = lipgloss.NewStyle().Width(m.width).Align(lipgloss.Center).Render(body)
+
+	help := lipgloss.NewStyle().
+		Italic(true).
+		Align(lipgloss.Center).
+		Width(m.width).
+		Render(more + "  |  [U] undo  |  [B] back  |  [Q] quit")
+
+	verticalSpace := max(m.height-lipgloss.Height(body)-lipgloss.Height(help), 0)
+	return lipgloss.JoinVertical(lipgloss.Top,
+		strings.Repeat("\n", verticalSpace/2),
+		body,
+		strings.Repeat("\n", verticalSpace-verticalSpace/2),
+		help,
+	)
+}
+
+// FormatUntil renders a positive duration the way people talk about when
+// something is due, e.g. "in 5h" or "in 3d".
+func FormatUntil(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("in %dm", max(int(d.Minutes()), 1))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("in %dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("in %dd", int(d.Hours()/24))
+	}
+}
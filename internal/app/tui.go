@@ -2,7 +2,9 @@ package app
 
 import (
 	"fmt"
+	"math/rand/v2"
 	"strings"
+	"time"
 
 	"github.com/telikz/spacdr/internal/domain"
 	"github.com/telikz/spacdr/internal/service"
@@ -13,6 +15,7 @@ import (
 
 type UIModel struct {
 	deck     *domain.Deck
+	queue    *sessionQueue
 	current  int
 	flipped  bool
 	quitting bool
@@ -22,21 +25,84 @@ type UIModel struct {
 	err      string
 	svc      service.DeckService
 	goBack   bool
+	shownAt  time.Time
+	mode     string
+
+	// input is the answer being typed in type mode; answer is its grade once
+	// submitted.
+	input  []rune
+	answer *service.AnswerResult
+
+	// choice holds the options offered in choice mode and picked the index
+	// chosen, or -1 before one is.
+	choice *service.Choice
+	picked int
+	rng    *rand.Rand
+
+	// history holds the session's ratings, latest last, so they can be undone.
+	history []rating
+
+	// pendingEdit is an edited card file that didn't validate, kept to be
+	// opened again for pendingEditCard. confirmDelete is the card waiting for
+	// confirmation that it should be deleted.
+	pendingEdit     string
+	pendingEditCard string
+	confirmDelete   string
+
+	// waiting is set while every card left is learning and none is due yet.
+	waiting bool
+
+	// readOnly says why the deck can't be changed, when another session
+	// holds its lock.
+	readOnly string
+
+	// summary is set once the queue is exhausted, summarizing the ratings
+	// made since startedAt. studyMore asks for another session of the deck.
+	startedAt time.Time
+	summary   *service.SessionSummary
+	studyMore bool
 }
 
-func NewUIModel(deck *domain.Deck, filePath string, svc service.DeckService) *UIModel {
-	return &UIModel{
-		deck:     deck,
-		current:  0,
-		flipped:  false,
-		quitting: false,
-		filePath: filePath,
-		svc:      svc,
+// rating is a rating made during the session and what it changed.
+type rating struct {
+	cardID   string
+	before   domain.CardState
+	wasRated bool
+	step     *service.LearningStep
+}
+
+// tickMsg refreshes the countdown to the next learning card.
+type tickMsg time.Time
+
+// NewUIModel starts a session over the cards in queue, given by ID in the
+// order they should be studied, answered according to mode. Cards go
+// through steps before they leave the session.
+func NewUIModel(deck *domain.Deck, queue []string, filePath string, svc service.DeckService, mode string, steps service.LearningSteps) *UIModel {
+	m := &UIModel{
+		deck:      deck,
+		queue:     newSessionQueue(deck, queue, steps),
+		current:   0,
+		flipped:   false,
+		quitting:  false,
+		filePath:  filePath,
+		svc:       svc,
+		shownAt:   time.Now(),
+		mode:      mode,
+		rng:       rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		startedAt: time.Now(),
 	}
+	m.prepareChoice()
+	return m
 }
 
 func (m *UIModel) Init() tea.Cmd {
-	return nil
+	return tick()
+}
+
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
 }
 
 func (m *UIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -44,7 +110,31 @@ func (m *UIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+	case editFinishedMsg:
+		m.finishEdit(msg)
+	case tickMsg:
+		if m.waiting {
+			m.advance()
+		}
+		return m, tick()
 	case tea.KeyMsg:
+		if m.confirmDelete != "" {
+			m.updateConfirmDelete(msg)
+			return m, nil
+		}
+		if m.summary != nil {
+			return m.updateSummary(msg)
+		}
+		if m.waiting {
+			return m.updateWaiting(msg)
+		}
+		if m.mode == domain.ModeType && m.answer == nil && m.currentCard() != nil {
+			return m.updateTyping(msg)
+		}
+		if m.mode == domain.ModeChoice && m.updateChoice(msg) {
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "b":
 			m.goBack = true
@@ -55,38 +145,192 @@ func (m *UIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "h", "l":
 			m.flipped = !m.flipped
 		case "j":
-			m.current = m.svc.NextCard(m.deck, m.current)
-			m.flipped = false
+			if m.current < m.queue.len()-1 {
+				m.showCard(m.current + 1)
+			} else {
+				m.advance()
+			}
 		case "k":
-			m.current = m.svc.PreviousCard(m.current)
-			m.flipped = false
+			m.showCard(m.svc.PreviousCard(m.current))
+
+		case "u", "ctrl+z":
+			m.undo()
+		case "e", "ctrl+e":
+			return m, m.editCard()
+		case "s":
+			m.toggleSuspended()
+		case "-":
+			m.bury()
 
 		case "1", "2", "3", "4", "5":
-			score := int(msg.String()[0] - '0')
-			err := m.svc.RateCard(m.deck, m.current, score)
-			if err != nil {
-				return nil, nil
-			}
-			err = m.svc.SaveDeck(m.filePath, m.deck)
-			if err != nil {
-				return nil, nil
+			m.rate(int(msg.String()[0] - '0'))
+		case "enter":
+			if m.answer != nil {
+				m.rate(m.answer.SuggestedScore)
 			}
-			m.current = m.svc.NextCard(m.deck, m.current)
-			m.flipped = false
 		}
 	}
 	return m, nil
 }
 
+// rate rates the current card, saves the deck and moves on to the next card.
+func (m *UIModel) rate(score int) {
+	if m.record(score) {
+		m.advance()
+	}
+}
+
+// record rates the current card and saves the deck, reporting whether both
+// succeeded.
+func (m *UIModel) record(score int) bool {
+	if m.currentCard() == nil || !m.writable() {
+		return false
+	}
+	id := m.queue.at(m.current)
+	before := m.currentCard().State()
+	// A card back at a learning step was scheduled when it was first rated,
+	// so the repeat is only logged.
+	rate := m.svc.RateCard
+	if m.queue.learningStep(id) != nil {
+		rate = m.svc.RateLearningStep
+	}
+	err := rate(m.deck, id, score, time.Since(m.shownAt))
+	if err != nil {
+		m.err = err.Error()
+		return false
+	}
+	m.history = append(m.history, rating{
+		cardID:   id,
+		before:   before,
+		wasRated: m.queue.rated[id],
+		step:     m.queue.learningStep(id),
+	})
+	m.queue.rate(id, before, score, time.Now())
+	err = m.svc.SaveDeck(m.filePath, m.deck)
+	if err != nil {
+		m.err = err.Error()
+		return false
+	}
+	m.err = ""
+	return true
+}
+
+// undo takes back the latest rating of the session that wasn't undone yet,
+// saves the deck and goes back to the card.
+func (m *UIModel) undo() {
+	if !m.writable() {
+		return
+	}
+	if len(m.history) == 0 {
+		m.err = "nothing to undo"
+		return
+	}
+	last := m.history[len(m.history)-1]
+	if err := m.svc.UndoRating(m.deck, last.cardID, last.before); err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.history = m.history[:len(m.history)-1]
+	m.queue.restore(last.cardID, last.wasRated, last.step)
+	m.summary = nil
+	m.waiting = false
+
+	m.err = ""
+	if err := m.svc.SaveDeck(m.filePath, m.deck); err != nil {
+		m.err = err.Error()
+	}
+	// Edits can move the card in the queue since it was rated.
+	if index, ok := m.queue.indexOf(last.cardID); ok {
+		m.showCard(index)
+	}
+}
+
+// toggleSuspended suspends the current card and moves on, or unsuspends it
+// if it was suspended earlier in the session.
+func (m *UIModel) toggleSuspended() {
+	card := m.currentCard()
+	if card == nil {
+		return
+	}
+	if card.Suspended {
+		m.shelve(card.ID, false, m.svc.UnsuspendCard)
+		return
+	}
+	m.shelve(card.ID, true, m.svc.SuspendCard)
+}
+
+// bury hides the current card until tomorrow and moves on.
+func (m *UIModel) bury() {
+	card := m.currentCard()
+	if card == nil {
+		return
+	}
+	m.shelve(card.ID, true, func(deck *domain.Deck, id string) error {
+		return m.svc.BuryCard(deck, id, time.Now())
+	})
+}
+
+// shelve applies change to the card, saves the deck and, if the card was
+// taken out of rotation, moves on to the next card.
+func (m *UIModel) shelve(id string, out bool, change func(*domain.Deck, string) error) {
+	if !m.writable() {
+		return
+	}
+	if err := change(m.deck, id); err != nil {
+		m.err = err.Error()
+		return
+	}
+	if err := m.svc.SaveDeck(m.filePath, m.deck); err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.err = ""
+	m.queue.shelve(id, out)
+	if out {
+		m.advance()
+	}
+}
+
+// writable reports whether the deck can be changed, showing why not if it
+// can't.
+func (m *UIModel) writable() bool {
+	if m.readOnly != "" {
+		m.err = "read-only: " + m.readOnly
+		return false
+	}
+	return true
+}
+
+func (m *UIModel) currentCard() *domain.Card {
+	if m.current < 0 || m.current >= m.queue.len() {
+		return nil
+	}
+	return m.deck.CardByID(m.queue.at(m.current))
+}
+
+// showCard moves to the card at index, front side up, and starts timing how
+// long it takes to rate it.
+func (m *UIModel) showCard(index int) {
+	m.current = index
+	m.flipped = false
+	m.shownAt = time.Now()
+	m.input = nil
+	m.answer = nil
+	m.prepareChoice()
+}
+
 func (m *UIModel) View() string {
-	if len(m.deck.Cards) == 0 {
-		errorStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("196")).
-			Bold(true)
-		return errorStyle.Render("No cards in deck")
+	if m.summary != nil {
+		return m.summaryView()
+	}
+	if m.waiting {
+		return m.waitingView()
+	}
+	card := m.currentCard()
+	if card == nil {
+		return m.emptyView()
 	}
 
-	card := m.deck.Cards[m.current]
 	content := card.Front
 	if m.flipped {
 		content = card.Back
@@ -113,17 +357,61 @@ func (m *UIModel) View() string {
 
 	contentStyle := lipgloss.NewStyle()
 
-	progress := fmt.Sprintf("(%d/%d)", m.current+1, len(m.deck.Cards))
+	progress := fmt.Sprintf("(%d/%d)", m.current+1, m.queue.len())
 	scoreStr := ""
 	if card.Score > 0 {
 		scoreStyle := lipgloss.NewStyle()
 		scoreStr = " " + scoreStyle.Render(fmt.Sprintf(" - %d/5", card.Score))
 	}
 
-	header := headerStyle.Render(fmt.Sprintf("%s  %s%s", m.deck.Name, progress, scoreStr))
-	cardBox := cardStyle.Render(contentStyle.Render(strings.TrimSpace(content)))
+	if m.readOnly != "" {
+		scoreStr += "  •  read-only"
+	}
+	switch {
+	case card.Suspended:
+		scoreStr += "  •  suspended"
+	case card.IsBuried(time.Now()):
+		scoreStr += "  •  buried"
+	case card.Leech:
+		scoreStr += "  •  leech"
+	}
 
-	help := "Rate: [1] [2] [3] [4] [5] \n" + "[H/L] flip  |  [J/K] navigate  |  [B] back"
+	reviewsLeft, newLeft, learning := m.queue.remaining()
+	remaining := fmt.Sprintf("  •  %d reviews, %d new left", reviewsLeft, newLeft)
+	if _, due, ok := m.queue.nextLearning(); ok {
+		next := "now"
+		if wait := time.Until(due); wait > 0 {
+			next = FormatUntil(wait)
+		}
+		remaining += fmt.Sprintf("  •  %d learning, next %s", learning, next)
+	}
+
+	header := headerStyle.Render(fmt.Sprintf("%s  %s%s%s", m.deck.Name, progress, scoreStr, remaining))
+	if m.err != "" {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Align(lipgloss.Center).
+			Width(m.width)
+		header = lipgloss.JoinVertical(lipgloss.Top, header, errorStyle.Render("✗ "+m.err))
+	}
+	if m.confirmDelete != "" {
+		promptStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("220")).
+			Bold(true).
+			Align(lipgloss.Center).
+			Width(m.width)
+		header = lipgloss.JoinVertical(lipgloss.Top, header, promptStyle.Render("Delete this card and its progress? [y/N]"))
+	}
+	cardBox := cardStyle.Render(contentStyle.Render(strings.TrimSpace(content)))
+	help := "Rate: [1] [2] [3] [4] [5]  |  [U] undo  |  [E] edit \n" + "[H/L] flip  |  [J/K] navigate  |  [S] suspend  |  [-] bury  |  [B] back"
+	switch {
+	case m.mode == domain.ModeType:
+		cardBox = lipgloss.JoinVertical(lipgloss.Center, cardBox, "", m.answerView(cardWidth))
+		help = m.typingHelp()
+	case m.mode == domain.ModeChoice && m.choice != nil:
+		cardBox = lipgloss.JoinVertical(lipgloss.Center, cardBox, "", m.choiceView(cardWidth))
+		help = m.choiceHelp()
+	}
 
 	helpStyle := lipgloss.NewStyle().
 		Italic(true).
@@ -166,3 +454,33 @@ func (m *UIModel) View() string {
 		helpText,
 	)
 }
+
+// emptyView is shown when there is nothing to study in this session.
+func (m *UIModel) emptyView() string {
+	if len(m.deck.Cards) == 0 {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Bold(true)
+		return errorStyle.Render("No cards in deck")
+	}
+
+	messageStyle := lipgloss.NewStyle().
+		Bold(true).
+		Align(lipgloss.Center).
+		Width(m.width)
+	helpStyle := lipgloss.NewStyle().
+		Italic(true).
+		Align(lipgloss.Center).
+		Width(m.width)
+
+	message := messageStyle.Render("All caught up! No cards in " + m.deck.Name + " are due right now.")
+	help := helpStyle.Render("[B] back  |  [Q] quit")
+
+	verticalSpace := max(m.height-lipgloss.Height(message)-lipgloss.Height(help), 0)
+	return lipgloss.JoinVertical(lipgloss.Top,
+		strings.Repeat("\n", verticalSpace/2),
+		message,
+		strings.Repeat("\n", verticalSpace-verticalSpace/2),
+		help,
+	)
+}
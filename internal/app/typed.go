@@ -0,0 +1,105 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/telikz/spacdr/internal/service"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// updateTyping handles keys while an answer is being typed. Every printable
+// key goes into the answer, so the usual single-letter shortcuts are off until
+// it is submitted.
+func (m *UIModel) updateTyping(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		return m, tea.Quit
+	case tea.KeyCtrlZ:
+		m.undo()
+	case tea.KeyCtrlE:
+		return m, m.editCard()
+	case tea.KeyEnter:
+		m.submitAnswer()
+	case tea.KeyEsc:
+		m.input = nil
+		m.submitAnswer()
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case tea.KeySpace:
+		m.input = append(m.input, ' ')
+	case tea.KeyRunes:
+		m.input = append(m.input, msg.Runes...)
+	}
+	return m, nil
+}
+
+// submitAnswer grades the typed answer and reveals the back of the card.
+func (m *UIModel) submitAnswer() {
+	card := m.currentCard()
+	if card == nil {
+		return
+	}
+	result := service.GradeAnswer(card.Answer(), string(m.input))
+	m.answer = &result
+	m.flipped = true
+}
+
+func (m *UIModel) answerView(width int) string {
+	boxStyle := lipgloss.NewStyle().
+		Width(width).
+		Align(lipgloss.Center)
+
+	if m.answer == nil {
+		return boxStyle.Render("› " + string(m.input) + "│")
+	}
+
+	var verdict string
+	switch {
+	case m.answer.Correct:
+		verdict = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Bold(true).Render("✓ Correct")
+	case m.answer.NearMiss:
+		verdict = lipgloss.NewStyle().Foreground(lipgloss.Color("220")).Bold(true).Render("≈ Almost")
+	default:
+		verdict = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true).Render("✗ Wrong")
+	}
+
+	lines := []string{verdict}
+	if len(m.input) > 0 && !m.answer.Correct {
+		lines = append(lines, renderDiff(m.answer.Diff))
+	}
+	lines = append(lines, fmt.Sprintf("Suggested rating: %d", m.answer.SuggestedScore))
+	return boxStyle.Render(lipgloss.JoinVertical(lipgloss.Center, lines...))
+}
+
+// renderDiff shows the typed answer with wrong characters struck through in
+// red and missing ones in green.
+func renderDiff(diff []service.DiffSegment) string {
+	extraStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Strikethrough(true)
+	missingStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Underline(true)
+
+	var b strings.Builder
+	for _, seg := range diff {
+		switch seg.Op {
+		case service.DiffExtra:
+			b.WriteString(extraStyle.Render(seg.Text))
+		case service.DiffMissing:
+			b.WriteString(missingStyle.Render(seg.Text))
+		default:
+			b.WriteString(seg.Text)
+		}
+	}
+	return b.String()
+}
+
+func (m *UIModel) typingHelp() string {
+	if m.answer == nil {
+		return "Type your answer  |  [Enter] check  |  [Esc] show answer  |  [Ctrl+Z] undo  |  [Ctrl+E] edit"
+	}
+	return "[Enter] accept rating  |  Rate: [1] [2] [3] [4] [5]  |  [U] undo  |  [E] edit \n" + "[J/K] navigate  |  [S] suspend  |  [-] bury  |  [B] back"
+}
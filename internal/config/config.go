@@ -1,16 +1,27 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
+	"github.com/telikz/spacdr/internal/repo"
+	"github.com/telikz/spacdr/internal/scheduler"
+	"github.com/telikz/spacdr/internal/service"
 )
 
 var (
 	spacdrDir string
 )
 
+// Storage backends for decks, progress and review logs.
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
 func init() {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -23,6 +34,27 @@ func GetSpacdrDir() string {
 	return spacdrDir
 }
 
+// GetStateDir returns the directory holding per-user study progress and
+// review logs, kept apart from the deck files so those can be shared.
+func GetStateDir() string {
+	return filepath.Join(spacdrDir, ".state")
+}
+
+// GetBackupDir returns the directory holding the backups of deck files.
+func GetBackupDir() string {
+	return filepath.Join(spacdrDir, ".backups")
+}
+
+// GetConfigPath returns the path of config.yaml.
+func GetConfigPath() string {
+	return filepath.Join(spacdrDir, "config.yaml")
+}
+
+// GetDatabasePath returns the SQLite database used when backend is sqlite.
+func GetDatabasePath() string {
+	return filepath.Join(spacdrDir, "collection.db")
+}
+
 func InitializeConfig() error {
 	if _, err := os.Stat(spacdrDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(spacdrDir, 0755); err != nil {
@@ -36,6 +68,15 @@ func InitializeConfig() error {
 	viper.SetConfigType("yaml")
 	viper.SetConfigName("config")
 	viper.AddConfigPath(spacdrDir)
+	viper.SetDefault("scheduler", scheduler.DefaultName)
+	viper.SetDefault("new_cards_per_day", service.DefaultNewCardsPerDay)
+	viper.SetDefault("reviews_per_day", service.DefaultReviewsPerDay)
+	viper.SetDefault("leech_threshold", service.DefaultLeechThreshold)
+	viper.SetDefault("leech_suspend", false)
+	viper.SetDefault("backups", repo.DefaultBackups)
+	viper.SetDefault("backend", BackendJSON)
+	viper.SetDefault("learning_steps", formatSteps(service.DefaultLearningSteps.Learning))
+	viper.SetDefault("relearning_steps", formatSteps(service.DefaultLearningSteps.Relearning))
 
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -49,3 +90,71 @@ func InitializeConfig() error {
 func GetDeckPath(deckRef string) string {
 	return filepath.Join(spacdrDir, deckRef+".json")
 }
+
+// GetScheduler returns the scheduler selected in config.yaml, used for every
+// deck that doesn't choose its own.
+func GetScheduler() (scheduler.Scheduler, error) {
+	return scheduler.New(viper.GetString("scheduler"))
+}
+
+// GetNewCardsPerDay returns how many new cards each deck introduces per day,
+// unless the deck sets its own limit.
+func GetNewCardsPerDay() int {
+	return viper.GetInt("new_cards_per_day")
+}
+
+// GetReviewsPerDay returns how many reviews each deck allows per day, unless
+// the deck sets its own limit.
+func GetReviewsPerDay() int {
+	return viper.GetInt("reviews_per_day")
+}
+
+// GetLeechThreshold returns how many lapses make a card a leech, or 0 if
+// leeches aren't tracked.
+func GetLeechThreshold() int {
+	return viper.GetInt("leech_threshold")
+}
+
+// GetLeechSuspend reports whether leeches are suspended automatically.
+func GetLeechSuspend() bool {
+	return viper.GetBool("leech_suspend")
+}
+
+// GetBackups returns how many backups are kept per deck, 0 turning backups
+// off.
+func GetBackups() int {
+	return viper.GetInt("backups")
+}
+
+// GetBackend returns where decks are stored: BackendJSON for deck files in the
+// spacdr directory, or BackendSQLite for the database at GetDatabasePath.
+func GetBackend() (string, error) {
+	switch backend := viper.GetString("backend"); backend {
+	case BackendJSON, BackendSQLite:
+		return backend, nil
+	default:
+		return "", fmt.Errorf("unknown backend %q (available: %s, %s)", backend, BackendJSON, BackendSQLite)
+	}
+}
+
+// GetLearningSteps returns the delays after which new and forgotten cards come
+// back within a session.
+func GetLearningSteps() (service.LearningSteps, error) {
+	learning, err := service.ParseLearningSteps(viper.GetStringSlice("learning_steps"))
+	if err != nil {
+		return service.LearningSteps{}, fmt.Errorf("learning_steps: %w", err)
+	}
+	relearning, err := service.ParseLearningSteps(viper.GetStringSlice("relearning_steps"))
+	if err != nil {
+		return service.LearningSteps{}, fmt.Errorf("relearning_steps: %w", err)
+	}
+	return service.LearningSteps{Learning: learning, Relearning: relearning}, nil
+}
+
+func formatSteps(steps []time.Duration) []string {
+	formatted := make([]string, len(steps))
+	for i, step := range steps {
+		formatted[i] = step.String()
+	}
+	return formatted
+}
@@ -11,6 +11,9 @@ type DeckInfo struct {
 	Category     string
 	FullPath     string
 	RelativePath string
+	// Tags are the tags used in the deck. DiscoverDecks doesn't read the
+	// decks, so it leaves them empty.
+	Tags []string
 }
 
 type CategoryDecks struct {
@@ -18,53 +21,82 @@ type CategoryDecks struct {
 	Decks    []DeckInfo
 }
 
+// DiscoverDecks returns the deck files in the spacdr directory, grouped by
+// category.
 func DiscoverDecks() ([]CategoryDecks, error) {
-	categoryMap := make(map[string][]DeckInfo)
+	paths, err := DeckFiles()
+	if err != nil {
+		return nil, err
+	}
+	return GroupDecks(paths), nil
+}
 
+// DeckFiles returns the paths of the deck files in the spacdr directory.
+func DeckFiles() ([]string, error) {
+	var paths []string
 	err := filepath.Walk(spacdrDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
+		// Hidden entries hold spacdr's own state, not decks.
+		if path != spacdrDir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
 		if info.IsDir() {
 			return nil
 		}
 
 		if strings.HasSuffix(path, ".json") && path != spacdrDir {
-			rel, err := filepath.Rel(spacdrDir, path)
-			if err != nil {
-				return err
-			}
+			paths = append(paths, path)
+		}
 
-			parts := strings.Split(rel, string(filepath.Separator))
-			var category string
-			var deckName string
+		return nil
+	})
 
-			if len(parts) == 1 {
-				category = ""
-				deckName = strings.TrimSuffix(parts[0], ".json")
-			} else {
-				category = parts[0]
-				deckName = strings.TrimSuffix(parts[len(parts)-1], ".json")
-			}
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
 
-			deckRef := strings.TrimSuffix(rel, ".json")
+// GroupDecks groups the decks at paths, which are under the spacdr directory,
+// by category.
+func GroupDecks(paths []string) []CategoryDecks {
+	categoryMap := make(map[string][]DeckInfo)
 
-			deckInfo := DeckInfo{
-				Name:         deckName,
-				Category:     category,
-				FullPath:     path,
-				RelativePath: deckRef,
-			}
+	for _, path := range paths {
+		rel, err := filepath.Rel(spacdrDir, path)
+		if err != nil {
+			continue
+		}
 
-			categoryMap[category] = append(categoryMap[category], deckInfo)
+		parts := strings.Split(rel, string(filepath.Separator))
+		var category string
+		var deckName string
+
+		if len(parts) == 1 {
+			category = ""
+			deckName = strings.TrimSuffix(parts[0], ".json")
+		} else {
+			category = parts[0]
+			deckName = strings.TrimSuffix(parts[len(parts)-1], ".json")
 		}
 
-		return nil
-	})
+		deckRef := strings.TrimSuffix(rel, ".json")
 
-	if err != nil {
-		return nil, err
+		deckInfo := DeckInfo{
+			Name:         deckName,
+			Category:     category,
+			FullPath:     path,
+			RelativePath: deckRef,
+		}
+
+		categoryMap[category] = append(categoryMap[category], deckInfo)
 	}
 
 	var result []CategoryDecks
@@ -84,5 +116,5 @@ func DiscoverDecks() ([]CategoryDecks, error) {
 		}
 	}
 
-	return result, nil
+	return result
 }
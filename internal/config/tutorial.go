@@ -7,11 +7,13 @@ import (
 	"time"
 
 	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/repo"
 )
 
 func CreateTutorialDeck() error {
 	tutorialDeck := &domain.Deck{
-		Name: "Tutorial Deck",
+		Version: repo.DeckVersion,
+		Name:    "Tutorial Deck",
 		Cards: []domain.Card{
 			{
 				Front:      "What is a flashcard?",
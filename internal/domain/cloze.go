@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ClozeHidden replaces a deletion on the front of the card asking for it,
+// unless the deletion has a hint.
+const ClozeHidden = "[...]"
+
+// clozePattern matches {{c1::answer}} and {{c1::answer::hint}}.
+var clozePattern = regexp.MustCompile(`\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+
+type clozeDeletion struct {
+	start, end int
+	ordinal    int
+	answer     string
+	hint       string
+}
+
+func parseCloze(text string) []clozeDeletion {
+	var deletions []clozeDeletion
+	for _, m := range clozePattern.FindAllStringSubmatchIndex(text, -1) {
+		ordinal, err := strconv.Atoi(text[m[2]:m[3]])
+		if err != nil || ordinal < 1 {
+			continue
+		}
+		d := clozeDeletion{start: m[0], end: m[1], ordinal: ordinal, answer: text[m[4]:m[5]]}
+		if m[6] >= 0 {
+			d.hint = text[m[6]:m[7]]
+		}
+		deletions = append(deletions, d)
+	}
+	return deletions
+}
+
+// ClozeOrdinals returns the deletion numbers used in text, in ascending order.
+func ClozeOrdinals(text string) []int {
+	var ordinals []int
+	for _, d := range parseCloze(text) {
+		if !slices.Contains(ordinals, d.ordinal) {
+			ordinals = append(ordinals, d.ordinal)
+		}
+	}
+	slices.Sort(ordinals)
+	return ordinals
+}
+
+// RenderCloze renders text for the card of the given deletion number. Its
+// deletions are hidden unless reveal is set; all others are shown.
+func RenderCloze(text string, ordinal int, reveal bool) string {
+	var b strings.Builder
+	last := 0
+	for _, d := range parseCloze(text) {
+		b.WriteString(text[last:d.start])
+		switch {
+		case d.ordinal != ordinal || reveal:
+			b.WriteString(d.answer)
+		case d.hint != "":
+			b.WriteString("[" + d.hint + "]")
+		default:
+			b.WriteString(ClozeHidden)
+		}
+		last = d.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// ClozeAnswer returns the text hidden on the card of the given deletion
+// number, with several deletions joined by commas.
+func ClozeAnswer(text string, ordinal int) string {
+	var answers []string
+	for _, d := range parseCloze(text) {
+		if d.ordinal == ordinal {
+			answers = append(answers, d.answer)
+		}
+	}
+	return strings.Join(answers, ", ")
+}
+
+// ClozeCardID is the ID of the card for a deletion number of a cloze entry.
+func ClozeCardID(noteID string, ordinal int) string {
+	return fmt.Sprintf("%s#c%d", noteID, ordinal)
+}
+
+// Answer is what the card asks for: the hidden text of a cloze card, or the
+// back of any other card.
+func (c *Card) Answer() string {
+	if c.Cloze != "" && c.Ordinal > 0 {
+		return ClozeAnswer(c.Cloze, c.Ordinal)
+	}
+	return c.Back
+}
+
+// expandCloze returns the cards of a cloze entry, one per deletion number.
+func expandCloze(entry Card) ([]Card, error) {
+	ordinals := ClozeOrdinals(entry.Cloze)
+	if len(ordinals) == 0 {
+		return nil, fmt.Errorf("cloze entry %q has no {{c1::...}} deletions", entry.ID)
+	}
+	cards := make([]Card, 0, len(ordinals))
+	for _, n := range ordinals {
+		back := RenderCloze(entry.Cloze, n, true)
+		if entry.Back != "" {
+			back += "\n\n" + entry.Back
+		}
+		cards = append(cards, Card{
+			ID:      ClozeCardID(entry.ID, n),
+			Front:   RenderCloze(entry.Cloze, n, false),
+			Back:    back,
+			Tags:    entry.Tags,
+			Cloze:   entry.Cloze,
+			Note:    entry.ID,
+			Ordinal: n,
+			Extra:   entry.Back,
+		})
+	}
+	return cards, nil
+}
@@ -1,15 +1,263 @@
 package domain
 
-import "time"
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
 
 type Card struct {
-	Front      string    `json:"front"`
-	Back       string    `json:"back"`
-	Score      int       `json:"score"`
-	LastReview time.Time `json:"last_review"`
+	ID    string   `json:"id"`
+	Front string   `json:"front,omitempty"`
+	Back  string   `json:"back,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+
+	// Type and Fields make the entry a note of one of the deck's note types,
+	// rendered into a card by its templates, see Deck.Expand.
+	Type   string            `json:"type,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+
+	// Cloze makes the entry a cloze note: text with {{c1::deletions}} that
+	// expands into one card per deletion number, see Deck.Expand. Back then
+	// holds optional extra text shown with the answer.
+	Cloze string `json:"cloze,omitempty"`
+
+	// Note is the ID of the entry a generated card was expanded from, and
+	// Ordinal its number among that entry's cards. Both are empty for plain
+	// front/back cards that don't have a reverse card.
+	Note    string `json:"-"`
+	Ordinal int    `json:"-"`
+	// Reverse marks the back-to-front card generated for an entry when the
+	// deck or its note type asks for reverse cards.
+	Reverse bool `json:"-"`
+	// Extra is the back text of the entry a generated card came from.
+	Extra string `json:"-"`
+
+	// Study progress. It is stored separately from the deck file, see
+	// CardState; the JSON tags only exist to read decks from before the split.
+	// Fields added since were never in deck files and have none.
+	Score       int       `json:"score,omitempty"`
+	LastReview  time.Time `json:"last_review,omitzero"`
+	EaseFactor  float64   `json:"ease_factor,omitempty"`
+	Interval    int       `json:"interval,omitempty"`
+	Repetitions int       `json:"repetitions,omitempty"`
+	Stability   float64   `json:"stability,omitempty"`
+	Difficulty  float64   `json:"difficulty,omitempty"`
+	Box         int       `json:"box,omitempty"`
+	Due         time.Time `json:"due,omitzero"`
+	Suspended   bool      `json:"-"`
+	BuriedUntil time.Time `json:"-"`
+	Lapses      int       `json:"-"`
+	Leech       bool      `json:"-"`
+}
+
+// CardState is the part of a card that changes when it is reviewed.
+type CardState struct {
+	Score       int       `json:"score"`
+	LastReview  time.Time `json:"last_review,omitzero"`
+	EaseFactor  float64   `json:"ease_factor,omitempty"`
+	Interval    int       `json:"interval,omitempty"`
+	Repetitions int       `json:"repetitions,omitempty"`
+	Stability   float64   `json:"stability,omitempty"`
+	Difficulty  float64   `json:"difficulty,omitempty"`
+	Box         int       `json:"box,omitempty"`
+	Due         time.Time `json:"due,omitzero"`
+	Suspended   bool      `json:"suspended,omitempty"`
+	BuriedUntil time.Time `json:"buried_until,omitzero"`
+	Lapses      int       `json:"lapses,omitempty"`
+	Leech       bool      `json:"leech,omitempty"`
+}
+
+func (s CardState) IsZero() bool {
+	return s == CardState{}
+}
+
+func (c *Card) State() CardState {
+	return CardState{
+		Score:       c.Score,
+		LastReview:  c.LastReview,
+		EaseFactor:  c.EaseFactor,
+		Interval:    c.Interval,
+		Repetitions: c.Repetitions,
+		Stability:   c.Stability,
+		Difficulty:  c.Difficulty,
+		Box:         c.Box,
+		Due:         c.Due,
+		Suspended:   c.Suspended,
+		BuriedUntil: c.BuriedUntil,
+		Lapses:      c.Lapses,
+		Leech:       c.Leech,
+	}
+}
+
+func (c *Card) SetState(s CardState) {
+	c.Score = s.Score
+	c.LastReview = s.LastReview
+	c.EaseFactor = s.EaseFactor
+	c.Interval = s.Interval
+	c.Repetitions = s.Repetitions
+	c.Stability = s.Stability
+	c.Difficulty = s.Difficulty
+	c.Box = s.Box
+	c.Due = s.Due
+	c.Suspended = s.Suspended
+	c.BuriedUntil = s.BuriedUntil
+	c.Lapses = s.Lapses
+	c.Leech = s.Leech
+}
+
+// IsDue reports whether the card should be reviewed at the given time.
+// Cards that have never been scheduled are always due.
+func (c *Card) IsDue(now time.Time) bool {
+	return c.Due.IsZero() || !c.Due.After(now)
+}
+
+// IsBuried reports whether the card is kept out of study until later.
+func (c *Card) IsBuried(now time.Time) bool {
+	return c.BuriedUntil.After(now)
+}
+
+// InRotation reports whether the card can be studied at the given time, i.e.
+// it is neither suspended nor buried.
+func (c *Card) InRotation(now time.Time) bool {
+	return !c.Suspended && !c.IsBuried(now)
+}
+
+// ContentID derives an identifier from the card's text, so the same card gets
+// the same ID on every machine. It is used to give legacy cards their ID.
+func ContentID(front, back string) string {
+	sum := sha1.Sum([]byte(front + "\x00" + back))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Study modes decide how a card is answered.
+const (
+	// ModeFlip shows the back on request and lets the user rate themselves.
+	ModeFlip = "flip"
+	// ModeType has the user type the answer, which is graded against the back.
+	ModeType = "type"
+	// ModeChoice has the user pick the back among answers of other cards.
+	ModeChoice = "choice"
+)
+
+// StudyModes lists the valid study modes.
+func StudyModes() []string {
+	return []string{ModeFlip, ModeType, ModeChoice}
+}
+
+func IsStudyMode(mode string) bool {
+	for _, m := range StudyModes() {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// DeckLimits overrides the daily limits from the config file for one deck.
+// Unset fields fall back to the config.
+type DeckLimits struct {
+	NewCardsPerDay *int `json:"new_cards_per_day,omitempty"`
+	ReviewsPerDay  *int `json:"reviews_per_day,omitempty"`
 }
 
 type Deck struct {
-	Name  string `json:"name"`
-	Cards []Card `json:"cards"`
+	// Version is the version of the deck file format the deck was written
+	// in. Files without one predate versioning.
+	Version   int         `json:"version,omitempty"`
+	Name      string      `json:"name"`
+	Scheduler string      `json:"scheduler,omitempty"`
+	Mode      string      `json:"mode,omitempty"`
+	Limits    *DeckLimits `json:"limits,omitempty"`
+	// Reverse adds a back-to-front card for every front/back entry and note.
+	Reverse   bool       `json:"reverse,omitempty"`
+	NoteTypes []NoteType `json:"note_types,omitempty"`
+	Cards     []Card     `json:"cards"`
+
+	// Path is the file the deck was loaded from.
+	Path string `json:"-"`
+}
+
+// CardByID returns the card with the given ID, or nil if there is none.
+func (d *Deck) CardByID(id string) *Card {
+	for i := range d.Cards {
+		if d.Cards[i].ID == id {
+			return &d.Cards[i]
+		}
+	}
+	return nil
+}
+
+// CardIDs returns the IDs of the deck's cards in file order.
+func (d *Deck) CardIDs() []string {
+	ids := make([]string, len(d.Cards))
+	for i := range d.Cards {
+		ids[i] = d.Cards[i].ID
+	}
+	return ids
+}
+
+// AssignCardIDs gives every card without an ID one derived from its content,
+// adding a numeric suffix when two cards have the same text. It reports
+// whether any card was changed.
+func (d *Deck) AssignCardIDs() bool {
+	used := make(map[string]bool, len(d.Cards))
+	for _, card := range d.Cards {
+		if card.ID != "" {
+			used[card.ID] = true
+		}
+	}
+
+	changed := false
+	for i := range d.Cards {
+		card := &d.Cards[i]
+		if card.ID != "" {
+			continue
+		}
+		base := ContentID(card.content())
+		id := base
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s-%d", base, n)
+		}
+		card.ID = id
+		used[id] = true
+		changed = true
+	}
+	return changed
+}
+
+// DuplicateCardID returns an ID that is used by more than one card, or an
+// empty string if all IDs are unique.
+func (d *Deck) DuplicateCardID() string {
+	seen := make(map[string]bool, len(d.Cards))
+	for _, card := range d.Cards {
+		if card.ID == "" {
+			continue
+		}
+		if seen[card.ID] {
+			return card.ID
+		}
+		seen[card.ID] = true
+	}
+	return ""
+}
+
+// ReviewLog is an immutable record of a single rating.
+type ReviewLog struct {
+	CardID     string        `json:"card_id"`
+	ReviewedAt time.Time     `json:"reviewed_at"`
+	Rating     int           `json:"rating"`
+	Scheduler  string        `json:"scheduler"`
+	Elapsed    time.Duration `json:"elapsed"`
+	TimeSpent  time.Duration `json:"time_spent"`
+	Before     CardState     `json:"before"`
+	After      CardState     `json:"after"`
+	// Undo marks an entry that takes back the card's latest rating. After is
+	// the state the card was restored to.
+	Undo bool `json:"undo,omitempty"`
+	// Repeat marks a rating of a card repeated at one of its learning steps,
+	// which doesn't reschedule the card. Repeats don't count as reviews
+	// towards the daily limit or retention.
+	Repeat bool `json:"repeat,omitempty"`
 }
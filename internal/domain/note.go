@@ -0,0 +1,296 @@
+package domain
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// BasicNoteType is the note type of plain front/back cards. It is always
+// available, and entries without a type use it.
+const BasicNoteType = "Basic"
+
+// NoteType describes entries made of named fields. Front and Back are
+// text/template templates executed with the entry's fields, e.g.
+// "{{.word}} ({{.reading}})".
+type NoteType struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+	Front  string   `json:"front"`
+	Back   string   `json:"back"`
+	// Reverse adds a back-to-front card for every note of this type.
+	Reverse bool `json:"reverse,omitempty"`
+}
+
+// Basic returns the built-in note type with a Front and a Back field.
+func Basic() NoteType {
+	return NoteType{
+		Name:   BasicNoteType,
+		Fields: []string{"Front", "Back"},
+		Front:  "{{.Front}}",
+		Back:   "{{.Back}}",
+	}
+}
+
+// NoteType returns the note type with the given name, either declared by the
+// deck or built in. An empty name is the Basic type.
+func (d *Deck) NoteType(name string) (NoteType, bool) {
+	if name == "" || name == BasicNoteType {
+		return Basic(), true
+	}
+	for _, t := range d.NoteTypes {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return NoteType{}, false
+}
+
+// noteTemplates is a note type with its templates parsed.
+type noteTemplates struct {
+	NoteType
+	front, back *template.Template
+}
+
+func (t NoteType) parse() (*noteTemplates, error) {
+	front, err := template.New(t.Name + " front").Option("missingkey=zero").Parse(t.Front)
+	if err != nil {
+		return nil, fmt.Errorf("note type %q: %w", t.Name, err)
+	}
+	back, err := template.New(t.Name + " back").Option("missingkey=zero").Parse(t.Back)
+	if err != nil {
+		return nil, fmt.Errorf("note type %q: %w", t.Name, err)
+	}
+	return &noteTemplates{NoteType: t, front: front, back: back}, nil
+}
+
+// render fills the templates with the fields of an entry. Fields the note
+// type doesn't declare are an error, so typos don't go unnoticed.
+func (t *noteTemplates) render(fields map[string]string) (front, back string, err error) {
+	for name := range fields {
+		if !slices.Contains(t.Fields, name) {
+			return "", "", fmt.Errorf("field %q is not part of note type %q", name, t.Name)
+		}
+	}
+	data := make(map[string]string, len(t.Fields))
+	for _, name := range t.Fields {
+		data[name] = fields[name]
+	}
+
+	var b strings.Builder
+	if err := t.front.Execute(&b, data); err != nil {
+		return "", "", err
+	}
+	front = b.String()
+	b.Reset()
+	if err := t.back.Execute(&b, data); err != nil {
+		return "", "", err
+	}
+	return front, b.String(), nil
+}
+
+// checkNoteTypes reports a note type that is unnamed, declared twice, or
+// shadows a built-in one.
+func (d *Deck) checkNoteTypes() error {
+	seen := make(map[string]bool, len(d.NoteTypes))
+	for _, t := range d.NoteTypes {
+		switch {
+		case t.Name == "":
+			return fmt.Errorf("note type without a name")
+		case t.Name == BasicNoteType:
+			return fmt.Errorf("note type %q is built in and can't be redeclared", t.Name)
+		case seen[t.Name]:
+			return fmt.Errorf("note type %q is declared twice", t.Name)
+		}
+		seen[t.Name] = true
+	}
+	return nil
+}
+
+// Expand replaces the deck's entries with the cards they generate, keeping
+// file order: a card per deletion number for cloze entries, and a card
+// rendered from its note type's templates for entries with fields, followed
+// by a reverse card where the deck or note type asks for one. Other front/back
+// entries stay as they are. Entries must have their IDs assigned first.
+func (d *Deck) Expand() error {
+	if err := d.checkNoteTypes(); err != nil {
+		return err
+	}
+
+	parsed := make(map[string]*noteTemplates)
+	cards := make([]Card, 0, len(d.Cards))
+	for _, entry := range d.Cards {
+		switch {
+		case entry.Note != "":
+			cards = append(cards, entry)
+
+		case entry.Cloze != "":
+			generated, err := expandCloze(entry)
+			if err != nil {
+				return err
+			}
+			cards = append(cards, generated...)
+
+		case entry.Type != "" || entry.Fields != nil:
+			t, ok := parsed[entry.Type]
+			if !ok {
+				noteType, found := d.NoteType(entry.Type)
+				if !found {
+					return fmt.Errorf("entry %q has unknown note type %q", entry.ID, entry.Type)
+				}
+				var err error
+				if t, err = noteType.parse(); err != nil {
+					return err
+				}
+				parsed[entry.Type] = t
+			}
+			front, back, err := t.render(entry.Fields)
+			if err != nil {
+				return fmt.Errorf("entry %q: %w", entry.ID, err)
+			}
+			card := Card{ID: entry.ID, Front: front, Back: back, Tags: entry.Tags, Type: entry.Type, Fields: entry.Fields}
+			cards = append(cards, withReverse(card, d.Reverse || t.Reverse)...)
+
+		default:
+			cards = append(cards, withReverse(entry, d.Reverse)...)
+		}
+	}
+	d.Cards = cards
+	return nil
+}
+
+// withReverse returns the card for an entry, followed by its back-to-front
+// card if reverse is set. Entries with reverse cards become notes, so the two
+// are known to be siblings.
+func withReverse(card Card, reverse bool) []Card {
+	if !reverse && card.Fields == nil && card.Type == "" {
+		return []Card{card}
+	}
+	card.Note = card.ID
+	card.Ordinal = 1
+	if !reverse {
+		return []Card{card}
+	}
+
+	back := card
+	back.ID = ReverseCardID(card.ID)
+	back.Front, back.Back = card.Back, card.Front
+	back.Ordinal = 2
+	back.Reverse = true
+	return []Card{card, back}
+}
+
+// ReverseCardID is the ID of the back-to-front card of an entry.
+func ReverseCardID(noteID string) string {
+	return noteID + "#r"
+}
+
+// Entries turns generated cards back into the entries they came from, the way
+// they are written to the deck file. Progress is left out.
+func (d *Deck) Entries() []Card {
+	entries := make([]Card, 0, len(d.Cards))
+	seen := make(map[string]bool)
+	for _, card := range d.Cards {
+		if card.Note == "" {
+			card.SetState(CardState{})
+			entries = append(entries, card)
+			continue
+		}
+		if seen[card.Note] {
+			continue
+		}
+		seen[card.Note] = true
+		entries = append(entries, card.entry())
+	}
+	return entries
+}
+
+// entry rebuilds the entry a generated card came from.
+func (c *Card) entry() Card {
+	e := *c
+	e.SetState(CardState{})
+	e.ID = c.Note
+	e.Note, e.Ordinal, e.Reverse, e.Extra = "", 0, false, ""
+	switch {
+	case c.Cloze != "":
+		e.Front, e.Back = "", c.Extra
+	case c.Type != "" || c.Fields != nil:
+		e.Front, e.Back = "", ""
+	case c.Reverse:
+		e.Front, e.Back = c.Back, c.Front
+	}
+	return e
+}
+
+// content returns the text an entry's ID is derived from.
+func (c *Card) content() (string, string) {
+	switch {
+	case c.Cloze != "":
+		return c.Cloze, c.Back
+	case c.Fields != nil:
+		var b strings.Builder
+		for _, name := range slices.Sorted(maps.Keys(c.Fields)) {
+			b.WriteString(name + "=" + c.Fields[name] + "\n")
+		}
+		return c.Type, b.String()
+	default:
+		return c.Front, c.Back
+	}
+}
+
+// EntryOf returns the entry the card with the given ID was generated from,
+// without progress: the card itself for plain front/back cards.
+func (d *Deck) EntryOf(cardID string) (Card, bool) {
+	card := d.CardByID(cardID)
+	if card == nil {
+		return Card{}, false
+	}
+	if card.Note != "" {
+		return card.entry(), true
+	}
+	entry := *card
+	entry.SetState(CardState{})
+	return entry, true
+}
+
+// ReplaceEntry swaps the entry of the card with the given ID for entry, or
+// removes it if entry is nil, and expands the deck again. The entry keeps its
+// ID, so cards that still exist afterwards keep their progress. If the new
+// entry doesn't expand the deck is left as it was.
+func (d *Deck) ReplaceEntry(cardID string, entry *Card) error {
+	old, ok := d.EntryOf(cardID)
+	if !ok {
+		return fmt.Errorf("no card with id %q", cardID)
+	}
+
+	states := make(map[string]CardState, len(d.Cards))
+	for i := range d.Cards {
+		states[d.Cards[i].ID] = d.Cards[i].State()
+	}
+
+	entries := d.Entries()
+	replaced := entries[:0:0]
+	for _, e := range entries {
+		switch {
+		case e.ID != old.ID:
+			replaced = append(replaced, e)
+		case entry != nil:
+			e = *entry
+			e.ID = old.ID
+			replaced = append(replaced, e)
+		}
+	}
+
+	cards := d.Cards
+	d.Cards = replaced
+	if err := d.Expand(); err != nil {
+		d.Cards = cards
+		return err
+	}
+	for i := range d.Cards {
+		d.Cards[i].SetState(states[d.Cards[i].ID])
+	}
+	return nil
+}
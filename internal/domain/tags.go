@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// LeechTag is the tag of cards marked as leeches. It is part of the study
+// progress rather than the deck, so it never shows up in deck files.
+const LeechTag = "leech"
+
+// HasTag reports whether the card has the tag, ignoring case.
+func (c *Card) HasTag(tag string) bool {
+	if c.Leech && strings.EqualFold(tag, LeechTag) {
+		return true
+	}
+	return slices.ContainsFunc(c.Tags, func(t string) bool {
+		return strings.EqualFold(t, tag)
+	})
+}
+
+// AllTags returns the card's tags, including LeechTag for leeches.
+func (c *Card) AllTags() []string {
+	if c.Leech && !slices.ContainsFunc(c.Tags, func(t string) bool { return strings.EqualFold(t, LeechTag) }) {
+		return append(slices.Clip(c.Tags), LeechTag)
+	}
+	return c.Tags
+}
+
+// TagCounts returns how many of the deck's cards have each tag. Tags that
+// only differ in case are counted together, under their first spelling.
+func (d *Deck) TagCounts() map[string]int {
+	counts := make(map[string]int)
+	spelling := make(map[string]string)
+	for _, card := range d.Cards {
+		for _, tag := range card.AllTags() {
+			key := strings.ToLower(tag)
+			if _, ok := spelling[key]; !ok {
+				spelling[key] = tag
+			}
+			counts[spelling[key]]++
+		}
+	}
+	return counts
+}
+
+// TagFilter selects cards by tag: those with any of the Include tags, or all
+// cards if there are none, minus those with any of the Exclude tags.
+type TagFilter struct {
+	Include []string
+	Exclude []string
+}
+
+func (f TagFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+func (f TagFilter) Match(c *Card) bool {
+	if len(f.Include) > 0 && !slices.ContainsFunc(f.Include, c.HasTag) {
+		return false
+	}
+	return !slices.ContainsFunc(f.Exclude, c.HasTag)
+}
@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+// DefaultBackups is how many backups are kept per deck.
+const DefaultBackups = 10
+
+// backupTimeLayout names backups after the time they were taken, in UTC, so
+// that they sort by name.
+const backupTimeLayout = "20060102-150405.000000000"
+
+// Backup is a copy of a deck file from before it was overwritten.
+type Backup struct {
+	Path string
+	Time time.Time
+}
+
+// BackupStore keeps the latest versions of each deck file.
+type BackupStore interface {
+	// Save backs up data, the deck file's current content, dropping the
+	// oldest backups beyond the ones kept.
+	Save(deckPath string, data []byte) error
+	// List returns the deck's backups, newest first.
+	List(deckPath string) ([]Backup, error)
+	// Restore puts the backup back in place of the deck file, backing up the
+	// file it replaces first.
+	Restore(deckPath string, backup Backup) error
+}
+
+// FileBackupStore keeps backups in a tree mirroring the decks, e.g.
+// <Dir>/spanish/vocabulary.20250102-150405.000000000.json for
+// <Root>/spanish/vocabulary.json. Without a directory or with nothing to keep
+// it doesn't back anything up.
+type FileBackupStore struct {
+	dir  StateDir
+	keep int
+}
+
+func NewFileBackupStore(opts ...Option) BackupStore {
+	o := newOptions(opts)
+	return &FileBackupStore{dir: o.backups, keep: o.keepBackups}
+}
+
+func (s *FileBackupStore) Save(deckPath string, data []byte) error {
+	if s.dir.Dir == "" || s.keep <= 0 {
+		return nil
+	}
+	stamp := time.Now().UTC().Format(backupTimeLayout)
+	if err := writeStateFile(s.dir.Path(deckPath, "."+stamp+".json"), data); err != nil {
+		return err
+	}
+
+	backups, err := s.List(deckPath)
+	if err != nil {
+		return err
+	}
+	for _, old := range backups[min(s.keep, len(backups)):] {
+		if err := os.Remove(old.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileBackupStore) List(deckPath string) ([]Backup, error) {
+	if s.dir.Dir == "" {
+		return nil, nil
+	}
+	prefix := s.dir.Path(deckPath, ".")
+	entries, err := os.ReadDir(filepath.Dir(prefix))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), filepath.Base(prefix))
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp, ok = strings.CutSuffix(stamp, ".json")
+		if !ok {
+			continue
+		}
+		// Other decks sharing the name's prefix, e.g. vocabulary.old.json,
+		// don't parse as a time.
+		taken, err := time.Parse(backupTimeLayout, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Path: filepath.Join(filepath.Dir(prefix), entry.Name()), Time: taken})
+	}
+	slices.SortFunc(backups, func(a, b Backup) int {
+		return b.Time.Compare(a.Time)
+	})
+	return backups, nil
+}
+
+func (s *FileBackupStore) Restore(deckPath string, backup Backup) error {
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return err
+	}
+	var deck domain.Deck
+	if err := json.Unmarshal(data, &deck); err != nil {
+		return fmt.Errorf("backup %s is not a valid deck: %w", backup.Path, err)
+	}
+	return ReplaceDeckFile(deckPath, data, s)
+}
+
+// ReplaceDeckFile writes data as the deck file at deckPath, backing up the file
+// it replaces in backups first. The new file replaces the old one atomically.
+func ReplaceDeckFile(deckPath string, data []byte, backups BackupStore) error {
+	current, err := os.ReadFile(deckPath)
+	switch {
+	case err == nil:
+		if err := backups.Save(deckPath, current); err != nil {
+			return fmt.Errorf("error backing up %s: %w", deckPath, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	return writeFileAtomic(deckPath, data, 0644)
+}
@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deck.json")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if string(data) != "new" {
+		t.Errorf("Expected new content, got %q", data)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected permissions 0600 to be kept, got %v", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files left behind, got %d entries", len(entries))
+	}
+}
+
+func TestWriteFileAtomicFollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "shared.json")
+	link := filepath.Join(dir, "deck.json")
+	if err := os.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	if err := writeFileAtomic(link, []byte("new"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected the symlink to be kept")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "new" {
+		t.Errorf("Expected the link target to be written, got %q", data)
+	}
+}
+
+func TestFileBackupStoreKeepsLatest(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "golang", "basics.json")
+	store := NewFileBackupStore(WithBackups(root, filepath.Join(root, ".backups"), 2))
+
+	for _, content := range []string{"v1", "v2", "v3"} {
+		if err := store.Save(deckPath, []byte(content)); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// A deck whose name starts the same isn't one of its backups.
+	other := filepath.Join(root, ".backups", "golang", "basics.old.json")
+	if err := os.WriteFile(other, []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	backups, err := store.List(deckPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups, got %d", len(backups))
+	}
+	if !strings.HasPrefix(backups[0].Path, filepath.Join(root, ".backups", "golang", "basics.")) {
+		t.Errorf("Expected backups to mirror the deck tree, got %s", backups[0].Path)
+	}
+	if data, _ := os.ReadFile(backups[0].Path); string(data) != "v3" {
+		t.Errorf("Expected the newest backup first, got %q", data)
+	}
+	if data, _ := os.ReadFile(backups[1].Path); string(data) != "v2" {
+		t.Errorf("Expected the oldest backup to be dropped, got %q", data)
+	}
+}
+
+func TestFileBackupStoreDisabled(t *testing.T) {
+	root := t.TempDir()
+	store := NewFileBackupStore(WithBackups(root, filepath.Join(root, ".backups"), 0))
+	if err := store.Save(filepath.Join(root, "deck.json"), []byte("v1")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".backups")); !os.IsNotExist(err) {
+		t.Errorf("Expected no backups to be written, got %v", err)
+	}
+}
+
+func TestFileDeckRepositoryBacksUpAndRestores(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	opts := []Option{
+		WithStateDir(root, filepath.Join(root, ".state")),
+		WithBackups(root, filepath.Join(root, ".backups"), DefaultBackups),
+	}
+	repo := NewFileDeckRepository(opts...)
+	store := NewFileBackupStore(opts...)
+
+	deck := &domain.Deck{Name: "Deck", Cards: []domain.Card{{ID: "a", Front: "Q1", Back: "A1"}}}
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	if backups, _ := store.List(deckPath); len(backups) != 0 {
+		t.Errorf("Expected a new deck file to need no backup, got %d", len(backups))
+	}
+
+	deck.Cards[0].Score = 4
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	if backups, _ := store.List(deckPath); len(backups) != 0 {
+		t.Errorf("Expected progress changes to leave the deck file alone, got %d backups", len(backups))
+	}
+
+	deck.Cards = nil
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	backups, err := store.List(deckPath)
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %d (%v)", len(backups), err)
+	}
+
+	if err := store.Restore(deckPath, backups[0]); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	restored, err := repo.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	if len(restored.Cards) != 1 || restored.Cards[0].Score != 4 {
+		t.Errorf("Expected the card and its progress back, got %+v", restored.Cards)
+	}
+	if backups, _ := store.List(deckPath); len(backups) != 2 {
+		t.Errorf("Expected the replaced deck file to be backed up, got %d backups", len(backups))
+	}
+}
+
+func TestFileBackupStoreRestoreInvalid(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	store := NewFileBackupStore(WithBackups(root, filepath.Join(root, ".backups"), DefaultBackups))
+	if err := store.Save(deckPath, []byte("{\"cards\": [")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	backups, _ := store.List(deckPath)
+	if err := store.Restore(deckPath, backups[0]); err == nil {
+		t.Errorf("Expected an error restoring a truncated backup")
+	}
+}
+
+func TestReplaceDeckFileBacksUp(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	store := NewFileBackupStore(WithBackups(root, filepath.Join(root, ".backups"), DefaultBackups))
+
+	if err := ReplaceDeckFile(deckPath, []byte(`{"name": "First"}`), store); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if backups, _ := store.List(deckPath); len(backups) != 0 {
+		t.Errorf("Expected no backup of a new deck, got %d", len(backups))
+	}
+
+	if err := ReplaceDeckFile(deckPath, []byte(`{"name": "Second"}`), store); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(deckPath); string(data) != `{"name": "Second"}` {
+		t.Errorf("Expected the deck to be replaced, got %s", data)
+	}
+	backups, _ := store.List(deckPath)
+	if len(backups) != 1 {
+		t.Fatalf("Expected the replaced deck to be backed up, got %d backups", len(backups))
+	}
+	if data, _ := os.ReadFile(backups[0].Path); string(data) != `{"name": "First"}` {
+		t.Errorf("Expected the backup to hold the replaced deck, got %s", data)
+	}
+}
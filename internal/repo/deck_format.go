@@ -0,0 +1,64 @@
+package repo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// DeckVersion is the version of the deck file format this build writes. Bump
+// it together with a migration in deckMigrations whenever the format changes
+// in a way older files need to be upgraded for.
+const DeckVersion = 1
+
+// ErrDeckTooNew is returned when loading a deck file written in a newer
+// format than this build supports.
+var ErrDeckTooNew = errors.New("deck file is newer than this version of spacdr supports")
+
+// deckMigration upgrades the JSON object of a deck file by one version,
+// changing it in place.
+type deckMigration func(deck map[string]json.RawMessage) error
+
+// deckMigrations upgrade deck files step by step: deckMigrations[v] turns a
+// file of version v into one of version v+1.
+var deckMigrations = []deckMigration{
+	// Version 1 added the version field. Older files are otherwise the
+	// same: cards without IDs and progress kept on the cards are still read,
+	// and move out on the next save.
+	0: func(deck map[string]json.RawMessage) error { return nil },
+}
+
+// upgradeDeck migrates the deck file data to the latest version known to
+// migrations, and reports whether it had to. Files of a later version fail
+// with ErrDeckTooNew.
+func upgradeDeck(data []byte, migrations []deckMigration) ([]byte, bool, error) {
+	var deck map[string]json.RawMessage
+	if err := json.Unmarshal(data, &deck); err != nil {
+		return nil, false, err
+	}
+
+	version := 0
+	if raw, ok := deck["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil || version < 0 {
+			return nil, false, fmt.Errorf("invalid version %s", raw)
+		}
+	}
+	if version > len(migrations) {
+		return nil, false, fmt.Errorf("%w: it is version %d, the latest supported is %d", ErrDeckTooNew, version, len(migrations))
+	}
+	if version == len(migrations) {
+		return data, false, nil
+	}
+
+	for v := version; v < len(migrations); v++ {
+		if err := migrations[v](deck); err != nil {
+			return nil, false, fmt.Errorf("error upgrading from version %d: %w", v, err)
+		}
+		deck["version"] = json.RawMessage(fmt.Sprint(v + 1))
+	}
+	upgraded, err := json.Marshal(deck)
+	if err != nil {
+		return nil, false, err
+	}
+	return upgraded, true, nil
+}
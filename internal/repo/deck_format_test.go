@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeckVersionMatchesMigrations(t *testing.T) {
+	if DeckVersion != len(deckMigrations) {
+		t.Errorf("Expected DeckVersion %d to match the %d migrations", DeckVersion, len(deckMigrations))
+	}
+}
+
+func TestUpgradeDeckStepByStep(t *testing.T) {
+	var steps []string
+	migrations := []deckMigration{
+		func(deck map[string]json.RawMessage) error {
+			steps = append(steps, "0")
+			deck["title"] = deck["name"]
+			return nil
+		},
+		func(deck map[string]json.RawMessage) error {
+			steps = append(steps, "1")
+			if _, ok := deck["title"]; !ok {
+				return errors.New("expected the first step to have run")
+			}
+			delete(deck, "name")
+			return nil
+		},
+	}
+
+	data, upgraded, err := upgradeDeck([]byte(`{"name": "Old", "cards": []}`), migrations)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !upgraded {
+		t.Error("Expected the deck to be upgraded")
+	}
+	if strings.Join(steps, ",") != "0,1" {
+		t.Errorf("Expected steps 0,1, got %v", steps)
+	}
+	var deck map[string]any
+	json.Unmarshal(data, &deck)
+	if deck["version"] != float64(2) || deck["title"] != "Old" || deck["name"] != nil {
+		t.Errorf("Unexpected upgraded deck: %s", data)
+	}
+
+	steps = nil
+	data, upgraded, err = upgradeDeck([]byte(`{"version": 1, "title": "Old"}`), migrations)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !upgraded || strings.Join(steps, ",") != "1" {
+		t.Errorf("Expected only step 1 to run, got %v", steps)
+	}
+
+	steps = nil
+	current := `{"version": 2, "title": "New"}`
+	data, upgraded, err = upgradeDeck([]byte(current), migrations)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if upgraded || len(steps) != 0 || string(data) != current {
+		t.Errorf("Expected a current deck to be left alone, got %s", data)
+	}
+}
+
+func TestUpgradeDeckFailures(t *testing.T) {
+	failing := []deckMigration{
+		func(deck map[string]json.RawMessage) error { return errors.New("boom") },
+	}
+	if _, _, err := upgradeDeck([]byte(`{"name": "X"}`), failing); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected the migration's error, got %v", err)
+	}
+
+	_, _, err := upgradeDeck([]byte(`{"version": 2}`), failing)
+	if !errors.Is(err, ErrDeckTooNew) {
+		t.Errorf("Expected ErrDeckTooNew, got %v", err)
+	}
+
+	if _, _, err := upgradeDeck([]byte(`{"version": "one"}`), failing); err == nil {
+		t.Error("Expected an error for an invalid version")
+	}
+}
+
+func TestFileDeckRepositoryUpgradesOldDecks(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	legacy := `{"name": "Legacy", "cards": [{"id": "a", "front": "Q", "back": "A"}]}`
+	if err := os.WriteFile(deckPath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	opts := []Option{
+		WithStateDir(root, filepath.Join(root, ".state")),
+		WithBackups(root, filepath.Join(root, ".backups"), DefaultBackups),
+	}
+	deck, err := NewFileDeckRepository(opts...).Load(deckPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if deck.Version != DeckVersion || deck.Name != "Legacy" || len(deck.Cards) != 1 {
+		t.Errorf("Unexpected upgraded deck: %+v", deck)
+	}
+
+	data, _ := os.ReadFile(deckPath)
+	var written struct {
+		Version int `json:"version"`
+	}
+	json.Unmarshal(data, &written)
+	if written.Version != DeckVersion {
+		t.Errorf("Expected the file to be written as version %d, got:\n%s", DeckVersion, data)
+	}
+
+	backups, err := NewFileBackupStore(opts...).List(deckPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("Expected the original to be backed up, got %d backups", len(backups))
+	}
+	if original, _ := os.ReadFile(backups[0].Path); string(original) != legacy {
+		t.Errorf("Expected the backup to hold the original, got %s", original)
+	}
+}
+
+func TestFileDeckRepositoryRejectsNewerDecks(t *testing.T) {
+	deckPath := filepath.Join(t.TempDir(), "deck.json")
+	newer := `{"version": 99, "name": "Future", "cards": []}`
+	if err := os.WriteFile(deckPath, []byte(newer), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	_, err := NewFileDeckRepository().Load(deckPath)
+	if !errors.Is(err, ErrDeckTooNew) {
+		t.Fatalf("Expected ErrDeckTooNew, got %v", err)
+	}
+	if data, _ := os.ReadFile(deckPath); string(data) != newer {
+		t.Errorf("Expected the newer file to be left alone, got %s", data)
+	}
+}
+
+func TestReadDeckFileLeavesFileAlone(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	legacy := `{"name": "Legacy", "cards": [{"front": "Q", "back": "A"}]}`
+	if err := os.WriteFile(deckPath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	deck, err := ReadDeckFile(deckPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if deck.Version != DeckVersion || len(deck.Cards) != 1 || deck.Cards[0].ID == "" {
+		t.Errorf("Unexpected deck: %+v", deck)
+	}
+
+	if data, _ := os.ReadFile(deckPath); string(data) != legacy {
+		t.Errorf("Expected the file to be left alone, got %s", data)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 1 {
+		t.Errorf("Expected nothing written next to the file, got %d entries", len(entries))
+	}
+}
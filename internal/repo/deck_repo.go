@@ -1,21 +1,51 @@
 package repo
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/telikz/spacdr/internal/domain"
 )
 
+// ErrDeckChanged is returned when saving a deck whose file was changed by
+// someone else since it was loaded.
+var ErrDeckChanged = errors.New("deck file was changed by another program since it was loaded")
+
 type DeckRepository interface {
 	Load(filePath string) (*domain.Deck, error)
 	Save(filePath string, deck *domain.Deck) error
 }
 
-type FileDeckRepository struct{}
+// FileDeckRepository stores deck content in JSON files and study progress in
+// a separate ProgressStore, combining the two on load. Deck files are only
+// written when their content changes, so studying never touches them.
+type FileDeckRepository struct {
+	progress ProgressStore
+	backups  BackupStore
+	locker   *FileLocker
+
+	// loaded remembers, per deck file, what it held and what the deck's
+	// content was when it was last loaded or saved. Mtimes can be too coarse
+	// to tell two writes apart, so changes are told by content hash.
+	loaded map[string]deckVersion
+}
+
+type deckVersion struct {
+	file    [sha256.Size]byte
+	content [sha256.Size]byte
+}
 
-func NewFileDeckRepository() DeckRepository {
-	return &FileDeckRepository{}
+func NewFileDeckRepository(opts ...Option) DeckRepository {
+	return &FileDeckRepository{
+		progress: NewFileProgressStore(opts...),
+		backups:  NewFileBackupStore(opts...),
+		locker:   NewFileLocker(opts...),
+		loaded:   make(map[string]deckVersion),
+	}
 }
 
 func (r *FileDeckRepository) Load(filePath string) (*domain.Deck, error) {
@@ -24,19 +54,176 @@ func (r *FileDeckRepository) Load(filePath string) (*domain.Deck, error) {
 		return nil, err
 	}
 
-	var deck domain.Deck
-	err = json.Unmarshal(data, &deck)
+	// Files from older versions of the format are upgraded in memory first,
+	// and written back below. Legacy decks get their IDs written back too, so
+	// they stay put when cards are edited later. The IDs are derived from the
+	// card text, so if the file can't be written they come out the same on
+	// the next load anyway.
+	deck, upgraded, assigned, err := parseDeck(filePath, data)
 	if err != nil {
 		return nil, err
 	}
 
-	return &deck, nil
+	progress, err := r.progress.Load(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading progress for %s: %w", filePath, err)
+	}
+
+	// Progress still stored inside an older deck file is used until the
+	// progress store has something newer, and moves there on the next save.
+	for i := range deck.Cards {
+		if state, ok := progress[deck.Cards[i].ID]; ok {
+			deck.Cards[i].SetState(state)
+		}
+	}
+
+	r.remember(filePath, data, deck)
+	if assigned || upgraded {
+		r.writeBack(filePath, deck)
+	}
+
+	return deck, nil
+}
+
+// writeBack saves a deck that loading upgraded or gave IDs to. Loading doesn't
+// need the deck's lock, so it is taken here, and the write-back is skipped
+// while anyone holds it: the deck comes out the same on the next load anyway.
+// Saving backs up the file as it was, so an upgrade can be rolled back with
+// spacdr restore.
+func (r *FileDeckRepository) writeBack(filePath string, deck *domain.Deck) {
+	lock, err := r.locker.Lock(filePath)
+	if err != nil {
+		return
+	}
+	defer lock.Unlock()
+	_ = r.Save(filePath, deck)
+}
+
+// ReadDeckFile loads the deck file at filePath the way Load does, without its
+// stored progress and without writing anything back. It is for files that
+// aren't decks of this repository, like ones being imported.
+func ReadDeckFile(filePath string) (*domain.Deck, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	deck, _, _, err := parseDeck(filePath, data)
+	return deck, err
 }
 
+// parseDeck decodes deck file data, upgrading older versions of the format and
+// assigning IDs to cards without one, and expands its notes into cards. It
+// reports whether either changed the deck's content.
+func parseDeck(filePath string, data []byte) (deck *domain.Deck, upgraded, assigned bool, err error) {
+	content, upgraded, err := upgradeDeck(data, deckMigrations)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("error in %s: %w", filePath, err)
+	}
+
+	deck = new(domain.Deck)
+	if err := json.Unmarshal(content, deck); err != nil {
+		return nil, false, false, err
+	}
+	deck.Path = filePath
+
+	if id := deck.DuplicateCardID(); id != "" {
+		return nil, false, false, fmt.Errorf("duplicate card id %q in %s", id, filePath)
+	}
+	assigned = deck.AssignCardIDs()
+
+	if err := deck.Expand(); err != nil {
+		return nil, false, false, fmt.Errorf("error in %s: %w", filePath, err)
+	}
+	if id := deck.DuplicateCardID(); id != "" {
+		return nil, false, false, fmt.Errorf("duplicate card id %q in %s", id, filePath)
+	}
+	return deck, upgraded, assigned, nil
+}
+
+// Save stores the deck's progress and, if its content changed, the deck file.
+// Cards without an ID are given one first, since progress is keyed by ID. The
+// previous deck file is backed up, and the new one replaces it atomically.
+//
+// If the deck file was changed by someone else since it was loaded, their
+// version is kept when the deck's content didn't change here, and Save fails
+// with ErrDeckChanged when it did.
 func (r *FileDeckRepository) Save(filePath string, deck *domain.Deck) error {
-	data, err := json.MarshalIndent(deck, "", "  ")
+	deck.AssignCardIDs()
+	if err := r.saveProgress(filePath, deck); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(contentOnly(deck), "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filePath, data, 0644)
+
+	existing, err := os.ReadFile(filePath)
+	if err == nil && bytes.Equal(existing, data) {
+		r.loaded[filePath] = deckVersion{file: sha256.Sum256(data), content: sha256.Sum256(data)}
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if loaded, ok := r.loaded[filePath]; ok && sha256.Sum256(existing) != loaded.file {
+		if sha256.Sum256(data) == loaded.content {
+			return nil
+		}
+		return fmt.Errorf("%w: %s", ErrDeckChanged, filePath)
+	}
+	if err == nil {
+		if err := r.backups.Save(filePath, existing); err != nil {
+			return fmt.Errorf("error backing up %s: %w", filePath, err)
+		}
+	}
+	if err := writeFileAtomic(filePath, data, 0644); err != nil {
+		return err
+	}
+	r.loaded[filePath] = deckVersion{file: sha256.Sum256(data), content: sha256.Sum256(data)}
+	return nil
+}
+
+// remember records the deck file's data and the deck's content as loaded, to
+// tell later whether either changed.
+func (r *FileDeckRepository) remember(filePath string, data []byte, deck *domain.Deck) {
+	content, err := json.MarshalIndent(contentOnly(deck), "", "  ")
+	if err != nil {
+		return
+	}
+	r.loaded[filePath] = deckVersion{file: sha256.Sum256(data), content: sha256.Sum256(content)}
+}
+
+// saveProgress updates the progress of the deck's cards, keeping entries for
+// cards that are no longer in the deck in case they come back.
+func (r *FileDeckRepository) saveProgress(filePath string, deck *domain.Deck) error {
+	progress, err := r.progress.Load(filePath)
+	if err != nil {
+		return fmt.Errorf("error loading progress for %s: %w", filePath, err)
+	}
+	hadProgress := len(progress) > 0
+
+	for i := range deck.Cards {
+		state := deck.Cards[i].State()
+		if state.IsZero() {
+			delete(progress, deck.Cards[i].ID)
+		} else {
+			progress[deck.Cards[i].ID] = state
+		}
+	}
+
+	if !hadProgress && len(progress) == 0 {
+		return nil
+	}
+	return r.progress.Save(filePath, progress)
+}
+
+// contentOnly returns a copy of the deck as written to its file: in the
+// latest format, without any study progress, and with generated cards folded
+// back into their entries.
+func contentOnly(deck *domain.Deck) *domain.Deck {
+	content := *deck
+	content.Version = DeckVersion
+	content.Cards = deck.Entries()
+	return &content
 }
@@ -1,8 +1,11 @@
 package repo
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"github.com/telikz/spacdr/internal/domain"
 	"testing"
 	"time"
@@ -110,3 +113,396 @@ func TestFileDeckRepositoryRoundTrip(t *testing.T) {
 		}
 	}
 }
+
+func TestFileDeckRepositoryLoadAssignsIDs(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "legacy.json")
+
+	legacy := `{"name": "Legacy", "cards": [
+		{"front": "Q1", "back": "A1", "score": 0},
+		{"front": "Q1", "back": "A1", "score": 0},
+		{"id": "custom", "front": "Q2", "back": "A2", "score": 3}
+	]}`
+	if err := os.WriteFile(filePath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	repo := NewFileDeckRepository()
+	first, err := repo.Load(filePath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+
+	ids := map[string]bool{}
+	for _, card := range first.Cards {
+		if card.ID == "" {
+			t.Fatalf("Card %q has no ID", card.Front)
+		}
+		ids[card.ID] = true
+	}
+	if len(ids) != 3 {
+		t.Errorf("Expected 3 unique IDs, got %v", ids)
+	}
+	if first.Cards[2].ID != "custom" {
+		t.Errorf("Expected existing ID to be kept, got %s", first.Cards[2].ID)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read deck: %v", err)
+	}
+	var saved domain.Deck
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse saved deck: %v", err)
+	}
+	for i, card := range saved.Cards {
+		if card.ID != first.Cards[i].ID {
+			t.Errorf("Card %d: expected persisted ID %s, got %q", i, first.Cards[i].ID, card.ID)
+		}
+	}
+}
+
+func TestFileDeckRepositoryLoadDuplicateIDs(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "dupes.json")
+	deck := `{"name": "Dupes", "cards": [
+		{"id": "same", "front": "Q1", "back": "A1"},
+		{"id": "same", "front": "Q2", "back": "A2"}
+	]}`
+	if err := os.WriteFile(filePath, []byte(deck), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	if _, err := NewFileDeckRepository().Load(filePath); err == nil {
+		t.Fatal("Expected error for duplicate card IDs, got nil")
+	}
+}
+
+func TestFileDeckRepositoryCloze(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "biology.json")
+	content := `{"name": "Biology", "cards": [
+		{"id": "cell", "front": "Basic unit of life?", "back": "The cell"},
+		{"id": "mito", "cloze": "The {{c1::mitochondria}} is the {{c2::powerhouse::role}} of the cell", "back": "Organelles"}
+	]}`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	repo := NewFileDeckRepository()
+	deck, err := repo.Load(filePath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+
+	if len(deck.Cards) != 3 {
+		t.Fatalf("Expected 3 cards, got %d", len(deck.Cards))
+	}
+	c1 := deck.CardByID("mito#c1")
+	c2 := deck.CardByID("mito#c2")
+	if c1 == nil || c2 == nil {
+		t.Fatalf("Expected cards mito#c1 and mito#c2, got %v", deck.CardIDs())
+	}
+	if c1.Front != "The [...] is the powerhouse of the cell" {
+		t.Errorf("Unexpected c1 front %q", c1.Front)
+	}
+	if c2.Front != "The mitochondria is the [role] of the cell" {
+		t.Errorf("Unexpected c2 front %q", c2.Front)
+	}
+	if c1.Back != "The mitochondria is the powerhouse of the cell\n\nOrganelles" {
+		t.Errorf("Unexpected c1 back %q", c1.Back)
+	}
+	if c2.Answer() != "powerhouse" {
+		t.Errorf("Expected answer 'powerhouse', got %q", c2.Answer())
+	}
+
+	c1.Score = 4
+	c1.LastReview = time.Now()
+	if err := repo.Save(filePath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read deck: %v", err)
+	}
+	var saved domain.Deck
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse saved deck: %v", err)
+	}
+	if len(saved.Cards) != 2 {
+		t.Fatalf("Expected 2 entries in the deck file, got %d", len(saved.Cards))
+	}
+	if saved.Cards[1].ID != "mito" || saved.Cards[1].Back != "Organelles" || saved.Cards[1].Front != "" {
+		t.Errorf("Expected the cloze entry to be written back as is, got %+v", saved.Cards[1])
+	}
+
+	reloaded, err := repo.Load(filePath)
+	if err != nil {
+		t.Fatalf("Failed to reload deck: %v", err)
+	}
+	if got := reloaded.CardByID("mito#c1").Score; got != 4 {
+		t.Errorf("Expected c1 score 4, got %d", got)
+	}
+	if got := reloaded.CardByID("mito#c2").Score; got != 0 {
+		t.Errorf("Expected c2 to be scheduled separately, got score %d", got)
+	}
+}
+
+func TestFileDeckRepositoryClozeWithoutDeletions(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "broken.json")
+	content := `{"name": "Broken", "cards": [{"id": "x", "cloze": "No deletions here"}]}`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	if _, err := NewFileDeckRepository().Load(filePath); err == nil {
+		t.Fatal("Expected error for a cloze entry without deletions, got nil")
+	}
+}
+
+func TestFileDeckRepositoryNoteTypes(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "japanese.json")
+	content := `{"name": "Japanese", "note_types": [{
+		"name": "Vocab",
+		"fields": ["word", "reading", "meaning", "example"],
+		"front": "{{.word}}",
+		"back": "{{.reading}}: {{.meaning}}{{if .example}}\n{{.example}}{{end}}"
+	}], "cards": [
+		{"id": "hola", "front": "Hola", "back": "Hello"},
+		{"id": "neko", "type": "Vocab", "fields": {"word": "猫", "reading": "ねこ", "meaning": "cat"}},
+		{"id": "inu", "type": "Vocab", "fields": {"word": "犬", "reading": "いぬ", "meaning": "dog", "example": "犬が好き"}},
+		{"id": "basic", "fields": {"Front": "Q", "Back": "A"}}
+	]}`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	repo := NewFileDeckRepository()
+	deck, err := repo.Load(filePath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+
+	cases := []struct{ id, front, back string }{
+		{"hola", "Hola", "Hello"},
+		{"neko", "猫", "ねこ: cat"},
+		{"inu", "犬", "いぬ: dog\n犬が好き"},
+		{"basic", "Q", "A"},
+	}
+	for _, c := range cases {
+		card := deck.CardByID(c.id)
+		if card == nil {
+			t.Fatalf("Expected card %s, got %v", c.id, deck.CardIDs())
+		}
+		if card.Front != c.front || card.Back != c.back {
+			t.Errorf("Card %s: expected %q / %q, got %q / %q", c.id, c.front, c.back, card.Front, card.Back)
+		}
+	}
+
+	deck.CardByID("neko").Score = 3
+	if err := repo.Save(filePath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read deck: %v", err)
+	}
+	var saved domain.Deck
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse saved deck: %v", err)
+	}
+	if len(saved.NoteTypes) != 1 {
+		t.Errorf("Expected note types to be kept, got %+v", saved.NoteTypes)
+	}
+	neko := saved.Cards[1]
+	if neko.Type != "Vocab" || neko.Fields["word"] != "猫" || neko.Front != "" || neko.Back != "" {
+		t.Errorf("Expected the note to be written back as fields, got %+v", neko)
+	}
+}
+
+func TestFileDeckRepositoryNoteTypeErrors(t *testing.T) {
+	cases := map[string]string{
+		"unknown type":     `{"name": "X", "cards": [{"id": "a", "type": "Vocab", "fields": {"word": "x"}}]}`,
+		"unknown field":    `{"name": "X", "note_types": [{"name": "Vocab", "fields": ["word"], "front": "{{.word}}", "back": ""}], "cards": [{"id": "a", "type": "Vocab", "fields": {"wrod": "x"}}]}`,
+		"bad template":     `{"name": "X", "note_types": [{"name": "Vocab", "fields": ["word"], "front": "{{.word", "back": ""}], "cards": [{"id": "a", "type": "Vocab", "fields": {"word": "x"}}]}`,
+		"basic redeclared": `{"name": "X", "note_types": [{"name": "Basic", "fields": ["word"], "front": "", "back": ""}], "cards": []}`,
+	}
+	for name, content := range cases {
+		filePath := filepath.Join(t.TempDir(), "deck.json")
+		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write deck: %v", err)
+		}
+		if _, err := NewFileDeckRepository().Load(filePath); err == nil {
+			t.Errorf("%s: expected error, got nil", name)
+		}
+	}
+}
+
+func TestFileDeckRepositoryReverse(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "spanish.json")
+	content := `{"name": "Spanish", "reverse": true, "cards": [
+		{"id": "hola", "front": "Hola", "back": "Hello"},
+		{"id": "gato", "cloze": "El {{c1::gato}} duerme"}
+	]}`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	repo := NewFileDeckRepository()
+	deck, err := repo.Load(filePath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	if len(deck.Cards) != 3 {
+		t.Fatalf("Expected forward, reverse and cloze cards, got %v", deck.CardIDs())
+	}
+	reverse := deck.CardByID("hola#r")
+	if reverse == nil || reverse.Front != "Hello" || reverse.Back != "Hola" {
+		t.Fatalf("Expected reverse card Hello -> Hola, got %+v", reverse)
+	}
+	if reverse.Note != "hola" || deck.CardByID("hola").Note != "hola" {
+		t.Errorf("Expected both directions to belong to note hola")
+	}
+
+	// Reordering the cards must not change what is written back.
+	deck.Cards[0], deck.Cards[1] = deck.Cards[1], deck.Cards[0]
+	reverse = deck.CardByID("hola#r")
+	reverse.Score = 2
+	if err := repo.Save(filePath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read deck: %v", err)
+	}
+	var saved domain.Deck
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse saved deck: %v", err)
+	}
+	if len(saved.Cards) != 2 {
+		t.Fatalf("Expected 2 entries in the deck file, got %d", len(saved.Cards))
+	}
+	if hola := saved.Cards[0]; hola.ID != "hola" || hola.Front != "Hola" || hola.Back != "Hello" {
+		t.Errorf("Expected the entry to be written forward, got %+v", hola)
+	}
+
+	reloaded, err := repo.Load(filePath)
+	if err != nil {
+		t.Fatalf("Failed to reload deck: %v", err)
+	}
+	if reloaded.CardByID("hola#r").Score != 2 || reloaded.CardByID("hola").Score != 0 {
+		t.Errorf("Expected the two directions to be scheduled separately")
+	}
+}
+
+func TestFileDeckRepositoryTags(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "go.json")
+	content := `{"name": "Go", "cards": [
+		{"id": "chan", "front": "Q", "back": "A", "tags": ["concurrency"]},
+		{"id": "gen", "cloze": "{{c1::Type parameters}} use {{c2::constraints}}", "tags": ["generics"]}
+	]}`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	repo := NewFileDeckRepository()
+	deck, err := repo.Load(filePath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	if !deck.CardByID("gen#c2").HasTag("Generics") {
+		t.Errorf("Expected cloze cards to carry the entry's tags")
+	}
+	counts := deck.TagCounts()
+	if counts["concurrency"] != 1 || counts["generics"] != 2 {
+		t.Errorf("Unexpected tag counts %v", counts)
+	}
+
+	if err := repo.Save(filePath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	reloaded, err := repo.Load(filePath)
+	if err != nil {
+		t.Fatalf("Failed to reload deck: %v", err)
+	}
+	if !reloaded.CardByID("chan").HasTag("concurrency") || !reloaded.CardByID("gen#c1").HasTag("generics") {
+		t.Errorf("Expected tags to be written back")
+	}
+}
+
+func TestFileDeckRepositorySuspendedIsProgress(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "deck.json")
+	buried := time.Now().Add(time.Hour).Truncate(time.Second)
+	deck := &domain.Deck{Name: "Deck", Cards: []domain.Card{
+		{ID: "a", Front: "Q1", Back: "A1", Suspended: true},
+		{ID: "b", Front: "Q2", Back: "A2", BuriedUntil: buried},
+	}}
+
+	repo := NewFileDeckRepository()
+	if err := repo.Save(filePath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read deck: %v", err)
+	}
+	if strings.Contains(string(data), "suspended") || strings.Contains(string(data), "buried_until") {
+		t.Errorf("Expected the deck file to hold no progress, got %s", data)
+	}
+
+	loaded, err := repo.Load(filePath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	if !loaded.CardByID("a").Suspended {
+		t.Error("Expected card a to stay suspended")
+	}
+	if !loaded.CardByID("b").BuriedUntil.Equal(buried) {
+		t.Errorf("Expected card b to be buried until %v, got %v", buried, loaded.CardByID("b").BuriedUntil)
+	}
+}
+
+func TestFileDeckRepositoryDetectsConcurrentChanges(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	content := `{"name": "Deck", "cards": [{"id": "a", "front": "Q1", "back": "A1"}]}`
+	if err := os.WriteFile(deckPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	repo := NewFileDeckRepository(WithStateDir(root, filepath.Join(root, ".state")))
+	deck, err := repo.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+
+	changed := `{"name": "Deck", "cards": [{"id": "a", "front": "Q1", "back": "A1"}, {"id": "b", "front": "Q2", "back": "A2"}]}`
+	if err := os.WriteFile(deckPath, []byte(changed), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	deck.Cards[0].Score = 4
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Expected progress to save when only the file changed, got %v", err)
+	}
+	if data, _ := os.ReadFile(deckPath); string(data) != changed {
+		t.Errorf("Expected the other program's changes to be kept, got %s", data)
+	}
+
+	deck.Cards[0].Back = "Edited"
+	err = repo.Save(deckPath, deck)
+	if !errors.Is(err, ErrDeckChanged) {
+		t.Fatalf("Expected ErrDeckChanged when both changed the deck, got %v", err)
+	}
+	if data, _ := os.ReadFile(deckPath); string(data) != changed {
+		t.Errorf("Expected the deck file to be left alone, got %s", data)
+	}
+
+	deck, err = repo.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to reload deck: %v", err)
+	}
+	deck.Cards[1].Back = "Edited"
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Errorf("Expected the deck to save after reloading it, got %v", err)
+	}
+}
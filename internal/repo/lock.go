@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrLocked is returned when another spacdr process holds the lock on a deck.
+var ErrLocked = errors.New("deck is open in another spacdr session")
+
+// DeckLock is an advisory lock on a deck, held by the process studying or
+// changing it. The operating system releases it when the process exits, so a
+// crash never leaves a deck locked.
+type DeckLock struct {
+	file *os.File
+}
+
+// FileLocker locks decks through lock files kept with the rest of their state,
+// e.g. spanish/vocabulary.lock for spanish/vocabulary.json. Locking is
+// supported on Unix systems and Windows; elsewhere every lock succeeds.
+type FileLocker struct {
+	state StateDir
+}
+
+func NewFileLocker(opts ...Option) *FileLocker {
+	return &FileLocker{state: newOptions(opts).state}
+}
+
+// Lock locks the deck, failing with ErrLocked if another process holds it.
+func (l *FileLocker) Lock(deckPath string) (*DeckLock, error) {
+	path := l.state.Path(deckPath, ".lock")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockFile(f); err != nil {
+		holder, _ := os.ReadFile(path)
+		f.Close()
+		if errors.Is(err, errWouldBlock) {
+			holder, _, _ := strings.Cut(string(holder), "\n")
+			if holder = strings.TrimSpace(holder); holder != "" {
+				return nil, fmt.Errorf("%w (%s)", ErrLocked, holder)
+			}
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+
+	// Who holds the lock is only there to be shown to other processes, so
+	// failing to write it doesn't matter.
+	if err := f.Truncate(0); err == nil {
+		fmt.Fprintf(f, "pid %d since %s\n", os.Getpid(), time.Now().Format("15:04"))
+	}
+	return &DeckLock{file: f}, nil
+}
+
+// Unlock releases the lock. The lock file is left in place, since removing it
+// could let two processes lock different files for the same deck.
+func (l *DeckLock) Unlock() error {
+	if err := unlockFile(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
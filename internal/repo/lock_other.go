@@ -0,0 +1,18 @@
+//go:build !unix && !windows
+
+package repo
+
+import (
+	"errors"
+	"os"
+)
+
+var errWouldBlock = errors.New("would block")
+
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
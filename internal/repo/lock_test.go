@@ -0,0 +1,73 @@
+//go:build unix || windows
+
+package repo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileLockerLock(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	locker := NewFileLocker(WithStateDir(root, filepath.Join(root, ".state")))
+
+	lock, err := locker.Lock(deckPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err = locker.Lock(deckPath)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("Expected ErrLocked for a locked deck, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "pid ") {
+		t.Errorf("Expected the error to name the process holding the lock, got %v", err)
+	}
+
+	other, err := locker.Lock(filepath.Join(root, "other.json"))
+	if err != nil {
+		t.Fatalf("Expected other decks to stay unlocked, got %v", err)
+	}
+	other.Unlock()
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	lock, err = locker.Lock(deckPath)
+	if err != nil {
+		t.Fatalf("Expected the deck to lock again once unlocked, got %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestFileDeckRepositoryWritesBackOnlyUnlocked(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	legacy := `{"name": "Legacy", "cards": [{"front": "Q", "back": "A"}]}`
+	if err := os.WriteFile(deckPath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+	opts := []Option{WithStateDir(root, filepath.Join(root, ".state"))}
+
+	lock, err := NewFileLocker(opts...).Lock(deckPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := NewFileDeckRepository(opts...).Load(deckPath); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(deckPath); string(data) != legacy {
+		t.Errorf("Expected a locked deck not to be written back, got %s", data)
+	}
+
+	lock.Unlock()
+	if _, err := NewFileDeckRepository(opts...).Load(deckPath); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(deckPath); string(data) == legacy {
+		t.Error("Expected an unlocked deck to be written back")
+	}
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package repo
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+var errWouldBlock = windows.ERROR_LOCK_VIOLATION
+
+// lockRange is where the lock is taken. Windows keeps other processes from
+// reading locked bytes, so it lies past the holder written at the start of
+// the file.
+var lockRange = windows.Overlapped{OffsetHigh: 1}
+
+func lockFile(f *os.File) error {
+	ol := lockRange
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errWouldBlock
+	}
+	return err
+}
+
+func unlockFile(f *os.File) error {
+	ol := lockRange
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &ol)
+}
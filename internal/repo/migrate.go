@@ -0,0 +1,99 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+// MigrateToSQLite copies the deck at path, with its progress and review log,
+// from JSON files into store. A deck already in the database is replaced. opts
+// locate the deck's state as for NewFileDeckRepository.
+func MigrateToSQLite(store *SQLiteStore, path string, opts ...Option) error {
+	deck, err := NewFileDeckRepository(opts...).Load(path)
+	if err != nil {
+		return fmt.Errorf("error loading %s: %w", path, err)
+	}
+	reviews, err := NewFileReviewLogRepository(opts...).Load(path)
+	if err != nil {
+		return fmt.Errorf("error loading review log of %s: %w", path, err)
+	}
+
+	deck.AssignCardIDs()
+	meta, err := deckMeta(deck)
+	if err != nil {
+		return err
+	}
+	decks := &SQLiteDeckRepository{store: store, loaded: make(map[string]*sqliteSnapshot)}
+	err = store.inTx(func(ctx context.Context, tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM decks WHERE path = ?`, store.key(path)); err != nil {
+			return err
+		}
+		saved, err := decks.save(ctx, tx, path, meta, deck.Entries(), deck)
+		if err != nil {
+			return err
+		}
+		return appendReviews(ctx, tx, saved.id, reviews)
+	})
+	if err != nil {
+		return fmt.Errorf("error migrating %s: %w", path, err)
+	}
+	return nil
+}
+
+// MigrateToJSON writes the deck at path in store, with its progress and review
+// log, back to JSON files. An existing deck file is backed up first if opts ask
+// for backups, and isn't replaced if it changes while being migrated.
+func MigrateToJSON(store *SQLiteStore, path string, opts ...Option) error {
+	deck, err := NewSQLiteDeckRepository(store).Load(path)
+	if err != nil {
+		return fmt.Errorf("error loading %s: %w", path, err)
+	}
+	reviews, err := NewSQLiteReviewLogRepository(store).Load(path)
+	if err != nil {
+		return fmt.Errorf("error loading review log of %s: %w", path, err)
+	}
+
+	// Loading the existing file lets Save notice it changing underneath.
+	files := NewFileDeckRepository(opts...)
+	if _, err := files.Load(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error loading existing %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := files.Save(path, deck); err != nil {
+		return fmt.Errorf("error writing %s: %w", path, err)
+	}
+	fileLogs := &FileReviewLogRepository{state: newOptions(opts).state}
+	if err := fileLogs.replace(path, reviews); err != nil {
+		return fmt.Errorf("error writing review log of %s: %w", path, err)
+	}
+	return nil
+}
+
+// replace rewrites the deck's review log to hold exactly entries.
+func (r *FileReviewLogRepository) replace(deckPath string, entries []domain.ReviewLog) error {
+	if len(entries) == 0 {
+		if err := os.Remove(r.Path(deckPath)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+	return writeStateFile(r.Path(deckPath), data)
+}
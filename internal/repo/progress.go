@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+// ProgressStore keeps each user's study progress for a deck, keyed by card ID.
+type ProgressStore interface {
+	Load(deckPath string) (map[string]domain.CardState, error)
+	Save(deckPath string, progress map[string]domain.CardState) error
+}
+
+type FileProgressStore struct {
+	state StateDir
+}
+
+func NewFileProgressStore(opts ...Option) ProgressStore {
+	return &FileProgressStore{state: newOptions(opts).state}
+}
+
+type progressFile struct {
+	Cards map[string]domain.CardState `json:"cards"`
+}
+
+func (s *FileProgressStore) path(deckPath string) string {
+	return s.state.Path(deckPath, ".progress.json")
+}
+
+func (s *FileProgressStore) Load(deckPath string) (map[string]domain.CardState, error) {
+	data, err := os.ReadFile(s.path(deckPath))
+	if os.IsNotExist(err) {
+		return map[string]domain.CardState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file progressFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Cards == nil {
+		file.Cards = map[string]domain.CardState{}
+	}
+	return file.Cards, nil
+}
+
+func (s *FileProgressStore) Save(deckPath string, progress map[string]domain.CardState) error {
+	data, err := json.MarshalIndent(progressFile{Cards: progress}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeStateFile(s.path(deckPath), data)
+}
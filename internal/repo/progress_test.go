@@ -0,0 +1,176 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+func TestStateDirPath(t *testing.T) {
+	root := filepath.Join("home", ".spacdr")
+	state := StateDir{Root: root, Dir: filepath.Join(root, ".state")}
+
+	got := state.Path(filepath.Join(root, "spanish", "vocabulary.json"), ".progress.json")
+	want := filepath.Join(root, ".state", "spanish", "vocabulary.progress.json")
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	got = state.Path(filepath.Join("home", ".spacdr-other", "deck.json"), ".progress.json")
+	want = filepath.Join("home", ".spacdr-other", ".progress", "deck.progress.json")
+	if got != want {
+		t.Errorf("Expected %s for a deck outside the root, got %s", want, got)
+	}
+}
+
+func TestFileDeckRepositoryKeepsProgressOutOfDeck(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	repo := NewFileDeckRepository(WithStateDir(root, filepath.Join(root, ".state")))
+
+	deck := &domain.Deck{
+		Name: "Shared",
+		Cards: []domain.Card{
+			{ID: "a", Front: "Q1", Back: "A1"},
+			{ID: "b", Front: "Q2", Back: "A2"},
+		},
+	}
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	content, err := os.ReadFile(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to read deck: %v", err)
+	}
+	info, _ := os.Stat(deckPath)
+
+	reviewed := time.Now().Add(-time.Hour).Truncate(time.Second)
+	deck.Cards[0].Score = 4
+	deck.Cards[0].LastReview = reviewed
+	deck.Cards[0].Interval = 6
+	deck.Cards[0].Due = reviewed.Add(6 * 24 * time.Hour)
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save progress: %v", err)
+	}
+
+	after, err := os.ReadFile(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to read deck: %v", err)
+	}
+	if string(after) != string(content) {
+		t.Errorf("Studying changed the deck file:\n%s", after)
+	}
+	if infoAfter, _ := os.Stat(deckPath); !infoAfter.ModTime().Equal(info.ModTime()) {
+		t.Error("Expected deck file not to be rewritten")
+	}
+	if strings.Contains(string(after), "score") || strings.Contains(string(after), "due") {
+		t.Errorf("Deck file contains progress:\n%s", after)
+	}
+
+	loaded, err := repo.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	card := loaded.CardByID("a")
+	if card.Score != 4 || card.Interval != 6 || !card.LastReview.Equal(reviewed) {
+		t.Errorf("Progress not restored: %+v", card)
+	}
+	if loaded.CardByID("b").Score != 0 {
+		t.Error("Expected unreviewed card to have no progress")
+	}
+}
+
+func TestFileDeckRepositoryProgressIsPerUser(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	alice := NewFileDeckRepository(WithStateDir(root, filepath.Join(root, ".alice")))
+	bob := NewFileDeckRepository(WithStateDir(root, filepath.Join(root, ".bob")))
+
+	deck := &domain.Deck{Name: "Shared", Cards: []domain.Card{{ID: "a", Front: "Q1", Back: "A1", Score: 5}}}
+	if err := alice.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+
+	loaded, err := bob.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	if loaded.Cards[0].Score != 0 {
+		t.Errorf("Expected Bob to start fresh, got score %d", loaded.Cards[0].Score)
+	}
+}
+
+func TestFileDeckRepositoryImportsInlineProgress(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "legacy.json")
+	legacy := `{"name": "Legacy", "cards": [
+		{"front": "Q1", "back": "A1", "score": 3, "last_review": "2025-10-20T14:30:00Z"}
+	]}`
+	if err := os.WriteFile(deckPath, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+	repo := NewFileDeckRepository(WithStateDir(root, filepath.Join(root, ".state")))
+
+	first, err := repo.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	if first.Cards[0].Score != 3 {
+		t.Fatalf("Expected inline score 3, got %d", first.Cards[0].Score)
+	}
+
+	content, err := os.ReadFile(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to read deck: %v", err)
+	}
+	if strings.Contains(string(content), "score") {
+		t.Errorf("Expected inline progress to be moved out of the deck:\n%s", content)
+	}
+
+	second, err := repo.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to reload deck: %v", err)
+	}
+	if second.Cards[0].Score != 3 || second.Cards[0].LastReview.IsZero() {
+		t.Errorf("Expected progress to survive the move, got %+v", second.Cards[0])
+	}
+}
+
+func TestFileDeckRepositoryKeepsProgressOfRemovedCards(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	repo := NewFileDeckRepository(WithStateDir(root, filepath.Join(root, ".state")))
+
+	deck := &domain.Deck{Name: "Deck", Cards: []domain.Card{
+		{ID: "a", Front: "Q1", Back: "A1", Score: 2},
+		{ID: "b", Front: "Q2", Back: "A2", Score: 5},
+	}}
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+
+	removed := &domain.Deck{Name: "Deck", Cards: deck.Cards[:1]}
+	if err := repo.Save(deckPath, removed); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+
+	restored := `{"name": "Deck", "cards": [
+		{"id": "a", "front": "Q1", "back": "A1"},
+		{"id": "b", "front": "Q2", "back": "A2"}
+	]}`
+	if err := os.WriteFile(deckPath, []byte(restored), 0644); err != nil {
+		t.Fatalf("Failed to write deck: %v", err)
+	}
+
+	loaded, err := repo.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	if loaded.CardByID("b").Score != 5 {
+		t.Errorf("Expected progress of the restored card to be kept, got %+v", loaded.CardByID("b"))
+	}
+}
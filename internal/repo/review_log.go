@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+type ReviewLogRepository interface {
+	Append(deckPath string, entry domain.ReviewLog) error
+	Load(deckPath string) ([]domain.ReviewLog, error)
+}
+
+// FileReviewLogRepository keeps one JSON Lines file per deck, stored with the
+// rest of the user's study state. Entries are only ever appended, never
+// rewritten.
+type FileReviewLogRepository struct {
+	state StateDir
+}
+
+func NewFileReviewLogRepository(opts ...Option) ReviewLogRepository {
+	return &FileReviewLogRepository{state: newOptions(opts).state}
+}
+
+// Path returns the review log for the deck, e.g.
+// spanish/vocabulary.reviews.jsonl for spanish/vocabulary.json.
+func (r *FileReviewLogRepository) Path(deckPath string) string {
+	return r.state.Path(deckPath, ".reviews.jsonl")
+}
+
+// path returns the review log for the deck, moving a log that was kept next
+// to the deck file by older versions into the state directory.
+func (r *FileReviewLogRepository) path(deckPath string) (string, error) {
+	path := r.Path(deckPath)
+	legacy := strings.TrimSuffix(deckPath, filepath.Ext(deckPath)) + ".reviews.jsonl"
+	if legacy == path {
+		return path, nil
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if _, err := os.Stat(legacy); err == nil {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return "", err
+			}
+			if err := os.Rename(legacy, path); err != nil {
+				return "", err
+			}
+		}
+	}
+	return path, nil
+}
+
+func (r *FileReviewLogRepository) Append(deckPath string, entry domain.ReviewLog) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	path, err := r.path(deckPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+
+	// A line left unfinished by a crash is kept to itself, so the entry isn't
+	// glued onto it.
+	data = append(data, '\n')
+	if info, err := f.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (r *FileReviewLogRepository) Load(deckPath string) ([]domain.ReviewLog, error) {
+	path, err := r.path(deckPath)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []domain.ReviewLog
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var entry domain.ReviewLog
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// An entry cut short by a crash or a full disk while it was
+			// appended is lost, but doesn't keep the rest from loading.
+			if cutShort(err, scanner.Bytes()) {
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// cutShort reports whether err, from decoding line, means the line ends before
+// its JSON does.
+func cutShort(err error, line []byte) bool {
+	var syntax *json.SyntaxError
+	return errors.As(err, &syntax) && syntax.Offset >= int64(len(line))
+}
@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+func TestReviewLogPath(t *testing.T) {
+	root := filepath.Join("home", ".spacdr")
+	state := filepath.Join(root, ".state")
+	logs := NewFileReviewLogRepository(WithStateDir(root, state)).(*FileReviewLogRepository)
+
+	got := logs.Path(filepath.Join(root, "spanish", "vocabulary.json"))
+	want := filepath.Join(state, "spanish", "vocabulary.reviews.jsonl")
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	got = logs.Path(filepath.Join("elsewhere", "deck.json"))
+	want = filepath.Join("elsewhere", ".progress", "deck.reviews.jsonl")
+	if got != want {
+		t.Errorf("Expected %s for a deck outside the root, got %s", want, got)
+	}
+}
+
+func TestFileReviewLogMovesLegacyLog(t *testing.T) {
+	root := t.TempDir()
+	deckPath := filepath.Join(root, "deck.json")
+	legacy := filepath.Join(root, "deck.reviews.jsonl")
+	if err := os.WriteFile(legacy, []byte(`{"card_id":"a","rating":4}`+"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write legacy log: %v", err)
+	}
+
+	logs := NewFileReviewLogRepository(WithStateDir(root, filepath.Join(root, ".state")))
+	entries, err := logs.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(entries) != 1 || entries[0].CardID != "a" {
+		t.Errorf("Expected legacy entry to be loaded, got %+v", entries)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("Expected legacy log to be moved out of the deck directory")
+	}
+}
+
+func TestFileReviewLogAppendAndLoad(t *testing.T) {
+	deckPath := filepath.Join(t.TempDir(), "deck.json")
+	logs := NewFileReviewLogRepository()
+
+	now := time.Now()
+	entries := []domain.ReviewLog{
+		{CardID: "a", ReviewedAt: now, Rating: 3, Scheduler: "sm2", TimeSpent: 2 * time.Second},
+		{CardID: "b", ReviewedAt: now, Rating: 1, Scheduler: "sm2", Elapsed: 24 * time.Hour,
+			Before: domain.CardState{Score: 4, Interval: 6}, After: domain.CardState{Score: 1, Interval: 1}},
+	}
+	for _, entry := range entries {
+		if err := logs.Append(deckPath, entry); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+
+	loaded, err := logs.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(loaded) != len(entries) {
+		t.Fatalf("Expected %d entries, got %d", len(entries), len(loaded))
+	}
+	if loaded[1].CardID != "b" || loaded[1].Elapsed != 24*time.Hour {
+		t.Errorf("Unexpected second entry: %+v", loaded[1])
+	}
+	if loaded[1].Before.Interval != 6 || loaded[1].After.Interval != 1 {
+		t.Errorf("Scheduler state not preserved: %+v", loaded[1])
+	}
+	if loaded[0].TimeSpent != 2*time.Second {
+		t.Errorf("Expected time spent 2s, got %v", loaded[0].TimeSpent)
+	}
+}
+
+func TestFileReviewLogAppendOnly(t *testing.T) {
+	deckPath := filepath.Join(t.TempDir(), "deck.json")
+	logs := NewFileReviewLogRepository().(*FileReviewLogRepository)
+
+	if err := logs.Append(deckPath, domain.ReviewLog{CardID: "a", Rating: 2}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	first, err := os.ReadFile(logs.Path(deckPath))
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+
+	if err := logs.Append(deckPath, domain.ReviewLog{CardID: "b", Rating: 5}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	second, err := os.ReadFile(logs.Path(deckPath))
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+
+	if string(second[:len(first)]) != string(first) {
+		t.Error("Existing log entries were rewritten")
+	}
+}
+
+func TestFileReviewLogLoadMissing(t *testing.T) {
+	logs := NewFileReviewLogRepository()
+	loaded, err := logs.Load(filepath.Join(t.TempDir(), "deck.json"))
+	if err != nil {
+		t.Fatalf("Expected no error for missing log, got %v", err)
+	}
+	if len(loaded) != 0 {
+		t.Errorf("Expected no entries, got %d", len(loaded))
+	}
+}
+
+func TestFileReviewLogRecoversFromCutOffLine(t *testing.T) {
+	deckPath := filepath.Join(t.TempDir(), "deck.json")
+	logs := NewFileReviewLogRepository().(*FileReviewLogRepository)
+
+	if err := logs.Append(deckPath, domain.ReviewLog{CardID: "a", Rating: 4}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	// A crash while appending leaves half an entry behind.
+	f, err := os.OpenFile(logs.Path(deckPath), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open log: %v", err)
+	}
+	f.WriteString(`{"card_id":"b","rat`)
+	f.Close()
+
+	loaded, err := logs.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Expected the cut-off line to be skipped, got %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].CardID != "a" {
+		t.Errorf("Expected only the complete entry, got %+v", loaded)
+	}
+
+	// The next entry starts on a line of its own, and both it and the entries
+	// before the cut-off one still load.
+	if err := logs.Append(deckPath, domain.ReviewLog{CardID: "c", Rating: 3}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+	loaded, err = logs.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].CardID != "a" || loaded[1].CardID != "c" {
+		t.Errorf("Expected entries a and c, got %+v", loaded)
+	}
+	data, _ := os.ReadFile(logs.Path(deckPath))
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 {
+		t.Errorf("Expected the new entry on its own line, got:\n%s", data)
+	}
+
+	// Other damage is still reported.
+	if err := os.WriteFile(logs.Path(deckPath), []byte("{\"card_id\": x}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+	if _, err := logs.Load(deckPath); err == nil {
+		t.Error("Expected a damaged entry to be reported")
+	}
+}
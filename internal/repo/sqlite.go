@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema keeps deck content, study progress and review logs in separate
+// tables so that a rating only touches the rows of the card it rated. Entries
+// and states are stored as the JSON deck and progress files use, with the
+// columns needed to order and query them alongside.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS decks (
+	id       INTEGER PRIMARY KEY,
+	path     TEXT NOT NULL UNIQUE,
+	meta     TEXT NOT NULL,
+	revision INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS entries (
+	deck_id  INTEGER NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+	id       TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	content  TEXT NOT NULL,
+	PRIMARY KEY (deck_id, id)
+);
+CREATE INDEX IF NOT EXISTS entries_position ON entries(deck_id, position);
+CREATE TABLE IF NOT EXISTS card_states (
+	deck_id   INTEGER NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+	card_id   TEXT NOT NULL,
+	due       INTEGER,
+	suspended INTEGER NOT NULL DEFAULT 0,
+	state     TEXT NOT NULL,
+	PRIMARY KEY (deck_id, card_id)
+);
+CREATE INDEX IF NOT EXISTS card_states_due ON card_states(deck_id, due);
+CREATE TABLE IF NOT EXISTS reviews (
+	id          INTEGER PRIMARY KEY,
+	deck_id     INTEGER NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+	card_id     TEXT NOT NULL,
+	reviewed_at INTEGER NOT NULL,
+	entry       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS reviews_deck_time ON reviews(deck_id, reviewed_at);
+CREATE INDEX IF NOT EXISTS reviews_deck_card ON reviews(deck_id, card_id);
+`
+
+// SQLiteStore is a collection of decks kept in a single SQLite database, for
+// collections too large to rewrite a JSON file on every rating. Decks are
+// still identified by the path of their JSON file, stored relative to Root so
+// the database can move with the collection.
+type SQLiteStore struct {
+	db   *sql.DB
+	root string
+}
+
+// OpenSQLiteStore opens the database at path, creating it if needed, for the
+// decks under root.
+func OpenSQLiteStore(path, root string) (*SQLiteStore, error) {
+	dsn := "file:" + filepath.ToSlash(path) +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(wal)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating database %s: %w", path, err)
+	}
+	return &SQLiteStore{db: db, root: root}, nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// DeckPaths returns the paths of the decks in the database, sorted.
+func (s *SQLiteStore) DeckPaths() ([]string, error) {
+	rows, err := s.db.Query(`SELECT path FROM decks WHERE meta != '' ORDER BY path`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		paths = append(paths, s.path(key))
+	}
+	return paths, rows.Err()
+}
+
+// key identifies the deck at deckPath in the database.
+func (s *SQLiteStore) key(deckPath string) string {
+	if s.root != "" {
+		rel, err := filepath.Rel(s.root, deckPath)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	if abs, err := filepath.Abs(deckPath); err == nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(deckPath)
+}
+
+// path turns a key back into the deck's path.
+func (s *SQLiteStore) path(key string) string {
+	path := filepath.FromSlash(key)
+	if filepath.IsAbs(path) || s.root == "" {
+		return path
+	}
+	return filepath.Join(s.root, path)
+}
+
+// deckID returns the row of the deck at deckPath, creating an empty one if
+// create is set. It fails with fs.ErrNotExist otherwise.
+func (s *SQLiteStore) deckID(ctx context.Context, tx *sql.Tx, deckPath string, create bool) (int64, error) {
+	key := s.key(deckPath)
+	var id int64
+	err := tx.QueryRowContext(ctx, `SELECT id FROM decks WHERE path = ?`, key).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		if !create {
+			return 0, fmt.Errorf("deck %s not found in database: %w", key, fs.ErrNotExist)
+		}
+		res, err := tx.ExecContext(ctx, `INSERT INTO decks (path, meta) VALUES (?, '')`, key)
+		if err != nil {
+			return 0, err
+		}
+		return res.LastInsertId()
+	}
+	return id, err
+}
+
+// inTx runs fn in a transaction, committing it if fn succeeds.
+func (s *SQLiteStore) inTx(fn func(ctx context.Context, tx *sql.Tx) error) error {
+	ctx := context.Background()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,319 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+// SQLiteDeckRepository keeps decks in a SQLiteStore. It remembers what each
+// deck held when it was loaded or saved, so saving only writes the rows of
+// the entries and card states that changed since.
+type SQLiteDeckRepository struct {
+	store  *SQLiteStore
+	loaded map[string]*sqliteSnapshot
+}
+
+// sqliteSnapshot is a deck as the database holds it.
+type sqliteSnapshot struct {
+	id       int64
+	revision int64
+	meta     string
+	entries  []domain.Card
+	states   map[string]domain.CardState
+}
+
+func NewSQLiteDeckRepository(store *SQLiteStore) DeckRepository {
+	return &SQLiteDeckRepository{store: store, loaded: make(map[string]*sqliteSnapshot)}
+}
+
+func (r *SQLiteDeckRepository) Load(filePath string) (*domain.Deck, error) {
+	var deck *domain.Deck
+	var snap *sqliteSnapshot
+	err := r.store.inTx(func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		snap, err = r.read(ctx, tx, filePath)
+		if err != nil {
+			return err
+		}
+		if snap.meta == "" {
+			return fmt.Errorf("deck %s not found in database: %w", r.store.key(filePath), fs.ErrNotExist)
+		}
+		deck = &domain.Deck{}
+		return json.Unmarshal([]byte(snap.meta), deck)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if deck.Version > DeckVersion {
+		return nil, fmt.Errorf("error in %s: %w: it is version %d, the latest supported is %d", filePath, ErrDeckTooNew, deck.Version, DeckVersion)
+	}
+
+	deck.Path = filePath
+	deck.Cards = cloneEntries(snap.entries)
+	if id := deck.DuplicateCardID(); id != "" {
+		return nil, fmt.Errorf("duplicate card id %q in %s", id, filePath)
+	}
+	if err := deck.Expand(); err != nil {
+		return nil, fmt.Errorf("error in %s: %w", filePath, err)
+	}
+	if id := deck.DuplicateCardID(); id != "" {
+		return nil, fmt.Errorf("duplicate card id %q in %s", id, filePath)
+	}
+	for i := range deck.Cards {
+		if state, ok := snap.states[deck.Cards[i].ID]; ok {
+			deck.Cards[i].SetState(state)
+		}
+	}
+
+	r.loaded[filePath] = snap
+	return deck, nil
+}
+
+// Save writes the deck's content and progress, touching only the rows that
+// changed since the deck was loaded or last saved. Progress of cards no longer
+// in the deck is kept in case they come back.
+//
+// If the deck's content was changed in the database by someone else since it
+// was loaded, their version is kept when the content didn't change here, and
+// Save fails with ErrDeckChanged when it did.
+func (r *SQLiteDeckRepository) Save(filePath string, deck *domain.Deck) error {
+	deck.AssignCardIDs()
+	meta, err := deckMeta(deck)
+	if err != nil {
+		return err
+	}
+	entries := deck.Entries()
+
+	var saved *sqliteSnapshot
+	err = r.store.inTx(func(ctx context.Context, tx *sql.Tx) error {
+		saved, err = r.save(ctx, tx, filePath, meta, entries, deck)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	r.loaded[filePath] = saved
+	return nil
+}
+
+// save writes the deck in tx and returns what the database holds afterwards.
+func (r *SQLiteDeckRepository) save(ctx context.Context, tx *sql.Tx, filePath, meta string, entries []domain.Card, deck *domain.Deck) (*sqliteSnapshot, error) {
+	snap := r.loaded[filePath]
+	keepTheirs := false
+	if snap != nil {
+		var revision int64
+		err := tx.QueryRowContext(ctx, `SELECT revision FROM decks WHERE id = ?`, snap.id).Scan(&revision)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			snap = nil
+		case err != nil:
+			return nil, err
+		case revision != snap.revision:
+			if meta != snap.meta || !equalEntries(entries, snap.entries) {
+				return nil, fmt.Errorf("%w: %s", ErrDeckChanged, filePath)
+			}
+			keepTheirs = true
+		}
+	}
+	if snap == nil {
+		var err error
+		if snap, err = r.read(ctx, tx, filePath); err != nil {
+			return nil, err
+		}
+	}
+
+	saved := &sqliteSnapshot{id: snap.id, revision: snap.revision, meta: snap.meta, entries: snap.entries}
+	if !keepTheirs && (meta != snap.meta || !equalEntries(entries, snap.entries)) {
+		if err := r.writeContent(ctx, tx, snap, meta, entries); err != nil {
+			return nil, err
+		}
+		saved.revision++
+		saved.meta = meta
+		saved.entries = cloneEntries(entries)
+		if _, err := tx.ExecContext(ctx, `UPDATE decks SET revision = ? WHERE id = ?`, saved.revision, snap.id); err != nil {
+			return nil, err
+		}
+	}
+	states, err := r.writeStates(ctx, tx, snap, deck)
+	if err != nil {
+		return nil, err
+	}
+	saved.states = states
+	return saved, nil
+}
+
+// read returns the deck at filePath as the database holds it, creating an
+// empty row for it if there is none.
+func (r *SQLiteDeckRepository) read(ctx context.Context, tx *sql.Tx, filePath string) (*sqliteSnapshot, error) {
+	id, err := r.store.deckID(ctx, tx, filePath, true)
+	if err != nil {
+		return nil, err
+	}
+	snap := &sqliteSnapshot{id: id, states: make(map[string]domain.CardState)}
+	err = tx.QueryRowContext(ctx, `SELECT meta, revision FROM decks WHERE id = ?`, id).Scan(&snap.meta, &snap.revision)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT content FROM entries WHERE deck_id = ? ORDER BY position`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var content string
+		var entry domain.Card
+		if err := rows.Scan(&content); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(content), &entry); err != nil {
+			return nil, fmt.Errorf("error reading card of %s: %w", filePath, err)
+		}
+		snap.entries = append(snap.entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	states, err := tx.QueryContext(ctx, `SELECT card_id, state FROM card_states WHERE deck_id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer states.Close()
+	for states.Next() {
+		var cardID, data string
+		var state domain.CardState
+		if err := states.Scan(&cardID, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &state); err != nil {
+			return nil, fmt.Errorf("error reading progress of %s: %w", filePath, err)
+		}
+		snap.states[cardID] = state
+	}
+	return snap, states.Err()
+}
+
+// writeContent updates the deck's metadata and the entries that were added,
+// changed or moved since snap, and deletes the ones that were removed.
+func (r *SQLiteDeckRepository) writeContent(ctx context.Context, tx *sql.Tx, snap *sqliteSnapshot, meta string, entries []domain.Card) error {
+	if meta != snap.meta {
+		if _, err := tx.ExecContext(ctx, `UPDATE decks SET meta = ? WHERE id = ?`, meta, snap.id); err != nil {
+			return err
+		}
+	}
+
+	type stored struct {
+		position int
+		entry    *domain.Card
+	}
+	before := make(map[string]stored, len(snap.entries))
+	for i := range snap.entries {
+		before[snap.entries[i].ID] = stored{position: i, entry: &snap.entries[i]}
+	}
+
+	for i := range entries {
+		old, ok := before[entries[i].ID]
+		delete(before, entries[i].ID)
+		if ok && old.position == i && equalEntry(old.entry, &entries[i]) {
+			continue
+		}
+		content, err := json.Marshal(entries[i])
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO entries (deck_id, id, position, content) VALUES (?, ?, ?, ?)
+			ON CONFLICT (deck_id, id) DO UPDATE SET position = excluded.position, content = excluded.content`,
+			snap.id, entries[i].ID, i, string(content))
+		if err != nil {
+			return err
+		}
+	}
+	for id := range before {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM entries WHERE deck_id = ? AND id = ?`, snap.id, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeStates updates the progress of the deck's cards that changed since
+// snap, and returns the progress the database holds afterwards.
+func (r *SQLiteDeckRepository) writeStates(ctx context.Context, tx *sql.Tx, snap *sqliteSnapshot, deck *domain.Deck) (map[string]domain.CardState, error) {
+	states := maps.Clone(snap.states)
+	for i := range deck.Cards {
+		id := deck.Cards[i].ID
+		state := deck.Cards[i].State()
+		old, ok := states[id]
+		switch {
+		case ok && old == state:
+		case state.IsZero():
+			if !ok {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM card_states WHERE deck_id = ? AND card_id = ?`, snap.id, id); err != nil {
+				return nil, err
+			}
+			delete(states, id)
+		default:
+			data, err := json.Marshal(state)
+			if err != nil {
+				return nil, err
+			}
+			var due sql.NullInt64
+			if !state.Due.IsZero() {
+				due = sql.NullInt64{Int64: state.Due.Unix(), Valid: true}
+			}
+			_, err = tx.ExecContext(ctx, `INSERT INTO card_states (deck_id, card_id, due, suspended, state) VALUES (?, ?, ?, ?, ?)
+				ON CONFLICT (deck_id, card_id) DO UPDATE SET due = excluded.due, suspended = excluded.suspended, state = excluded.state`,
+				snap.id, id, due, state.Suspended, string(data))
+			if err != nil {
+				return nil, err
+			}
+			states[id] = state
+		}
+	}
+	return states, nil
+}
+
+// deckMeta returns everything about the deck but its cards, as JSON.
+func deckMeta(deck *domain.Deck) (string, error) {
+	meta := *deck
+	meta.Version = DeckVersion
+	meta.Cards = nil
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func cloneEntries(entries []domain.Card) []domain.Card {
+	cloned := slices.Clone(entries)
+	for i := range cloned {
+		cloned[i].Tags = slices.Clone(cloned[i].Tags)
+		cloned[i].Fields = maps.Clone(cloned[i].Fields)
+	}
+	return cloned
+}
+
+func equalEntries(a, b []domain.Card) bool {
+	return slices.EqualFunc(a, b, func(x, y domain.Card) bool {
+		return equalEntry(&x, &y)
+	})
+}
+
+// equalEntry compares the content of two entries.
+func equalEntry(a, b *domain.Card) bool {
+	return a.ID == b.ID && a.Front == b.Front && a.Back == b.Back &&
+		a.Cloze == b.Cloze && a.Type == b.Type &&
+		slices.Equal(a.Tags, b.Tags) && maps.Equal(a.Fields, b.Fields)
+}
@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/fs"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+// SQLiteReviewLogRepository keeps review logs in a SQLiteStore, one row per
+// entry. Like the JSON Lines files, entries are only ever appended.
+type SQLiteReviewLogRepository struct {
+	store *SQLiteStore
+}
+
+func NewSQLiteReviewLogRepository(store *SQLiteStore) ReviewLogRepository {
+	return &SQLiteReviewLogRepository{store: store}
+}
+
+func (r *SQLiteReviewLogRepository) Append(deckPath string, entry domain.ReviewLog) error {
+	return r.store.inTx(func(ctx context.Context, tx *sql.Tx) error {
+		id, err := r.store.deckID(ctx, tx, deckPath, true)
+		if err != nil {
+			return err
+		}
+		return appendReviews(ctx, tx, id, []domain.ReviewLog{entry})
+	})
+}
+
+func (r *SQLiteReviewLogRepository) Load(deckPath string) ([]domain.ReviewLog, error) {
+	var logs []domain.ReviewLog
+	err := r.store.inTx(func(ctx context.Context, tx *sql.Tx) error {
+		id, err := r.store.deckID(ctx, tx, deckPath, false)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, `SELECT entry FROM reviews WHERE deck_id = ? ORDER BY id`, id)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var data string
+			var entry domain.ReviewLog
+			if err := rows.Scan(&data); err != nil {
+				return err
+			}
+			if err := json.Unmarshal([]byte(data), &entry); err != nil {
+				return err
+			}
+			logs = append(logs, entry)
+		}
+		return rows.Err()
+	})
+	return logs, err
+}
+
+func appendReviews(ctx context.Context, tx *sql.Tx, deckID int64, entries []domain.ReviewLog) error {
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO reviews (deck_id, card_id, reviewed_at, entry) VALUES (?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, deckID, entry.CardID, entry.ReviewedAt.UnixNano(), string(data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package repo
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+func openTestStore(t *testing.T, root string) *SQLiteStore {
+	t.Helper()
+	store, err := OpenSQLiteStore(filepath.Join(root, "collection.db"), root)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteDeckRepositoryRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	store := openTestStore(t, root)
+	repo := NewSQLiteDeckRepository(store)
+	deckPath := filepath.Join(root, "spanish", "verbs.json")
+
+	deck := &domain.Deck{
+		Name:    "Verbs",
+		Reverse: true,
+		Cards: []domain.Card{
+			{ID: "a", Front: "hablar", Back: "to speak", Tags: []string{"ar"}},
+			{ID: "b", Cloze: "{{c1::Madrid}} is the capital of {{c2::Spain}}"},
+		},
+	}
+	if err := deck.Expand(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	reviewed := time.Now().Add(-time.Hour).Truncate(time.Second)
+	deck.CardByID("a#r").Score = 4
+	deck.CardByID("a#r").LastReview = reviewed
+	deck.CardByID("b#c2").Suspended = true
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+
+	loaded, err := NewSQLiteDeckRepository(store).Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	if loaded.Name != "Verbs" || !loaded.Reverse {
+		t.Errorf("Deck settings not preserved: %+v", loaded)
+	}
+	if len(loaded.Cards) != 4 {
+		t.Fatalf("Expected 4 cards, got %d", len(loaded.Cards))
+	}
+	if card := loaded.CardByID("a#r"); card.Score != 4 || !card.LastReview.Equal(reviewed) {
+		t.Errorf("Progress not restored: %+v", card)
+	}
+	if !loaded.CardByID("b#c2").Suspended || loaded.CardByID("b#c1").Suspended {
+		t.Error("Expected only the second cloze card to be suspended")
+	}
+	if entries := loaded.Entries(); entries[0].Tags[0] != "ar" || entries[1].Cloze == "" {
+		t.Errorf("Entries not preserved: %+v", entries)
+	}
+
+	paths, err := store.DeckPaths()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != deckPath {
+		t.Errorf("Expected deck paths [%s], got %v", deckPath, paths)
+	}
+}
+
+func TestSQLiteDeckRepositoryLoadNotFound(t *testing.T) {
+	root := t.TempDir()
+	repo := NewSQLiteDeckRepository(openTestStore(t, root))
+
+	_, err := repo.Load(filepath.Join(root, "missing.json"))
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestSQLiteDeckRepositoryUpdatesIncrementally(t *testing.T) {
+	root := t.TempDir()
+	store := openTestStore(t, root)
+	repo := NewSQLiteDeckRepository(store)
+	deckPath := filepath.Join(root, "deck.json")
+
+	deck := &domain.Deck{Cards: []domain.Card{
+		{ID: "a", Front: "Q1", Back: "A1"},
+		{ID: "b", Front: "Q2", Back: "A2"},
+		{ID: "c", Front: "Q3", Back: "A3"},
+	}}
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	var revision int64
+	store.db.QueryRow(`SELECT revision FROM decks`).Scan(&revision)
+
+	// Rating a card only writes its progress.
+	deck.Cards[1].Score = 3
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save progress: %v", err)
+	}
+	var after int64
+	store.db.QueryRow(`SELECT revision FROM decks`).Scan(&after)
+	if after != revision {
+		t.Errorf("Expected progress not to change the deck's revision, got %d -> %d", revision, after)
+	}
+	var states int
+	store.db.QueryRow(`SELECT COUNT(*) FROM card_states`).Scan(&states)
+	if states != 1 {
+		t.Errorf("Expected 1 card state row, got %d", states)
+	}
+
+	// Editing and removing cards only touches their rows.
+	deck.Cards[0].Back = "changed"
+	deck.Cards = deck.Cards[:2]
+	if err := repo.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save edit: %v", err)
+	}
+	var entries int
+	store.db.QueryRow(`SELECT COUNT(*) FROM entries`).Scan(&entries)
+	if entries != 2 {
+		t.Errorf("Expected 2 entry rows, got %d", entries)
+	}
+
+	loaded, err := NewSQLiteDeckRepository(store).Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	if len(loaded.Cards) != 2 || loaded.Cards[0].Back != "changed" || loaded.Cards[1].Score != 3 {
+		t.Errorf("Unexpected deck after updates: %+v", loaded.Cards)
+	}
+}
+
+func TestSQLiteDeckRepositoryDetectsConcurrentChanges(t *testing.T) {
+	root := t.TempDir()
+	store := openTestStore(t, root)
+	deckPath := filepath.Join(root, "deck.json")
+	if err := NewSQLiteDeckRepository(store).Save(deckPath, &domain.Deck{Cards: []domain.Card{{ID: "a", Front: "Q", Back: "A"}}}); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+
+	ours := NewSQLiteDeckRepository(store)
+	deck, err := ours.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+
+	theirs := NewSQLiteDeckRepository(store)
+	other, _ := theirs.Load(deckPath)
+	other.Cards = append(other.Cards, domain.Card{ID: "b", Front: "Q2", Back: "A2"})
+	if err := theirs.Save(deckPath, other); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+
+	// Progress alone keeps their content.
+	deck.Cards[0].Score = 4
+	if err := ours.Save(deckPath, deck); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	loaded, _ := NewSQLiteDeckRepository(store).Load(deckPath)
+	if len(loaded.Cards) != 2 || loaded.CardByID("a").Score != 4 {
+		t.Errorf("Expected their card and our progress, got %+v", loaded.Cards)
+	}
+
+	deck.Cards[0].Back = "edited"
+	if err := ours.Save(deckPath, deck); !errors.Is(err, ErrDeckChanged) {
+		t.Errorf("Expected ErrDeckChanged, got %v", err)
+	}
+}
+
+func TestSQLiteReviewLogAppendAndLoad(t *testing.T) {
+	root := t.TempDir()
+	logs := NewSQLiteReviewLogRepository(openTestStore(t, root))
+	deckPath := filepath.Join(root, "deck.json")
+
+	if loaded, err := logs.Load(deckPath); err != nil || len(loaded) != 0 {
+		t.Fatalf("Expected no entries for a new deck, got %v, %v", loaded, err)
+	}
+
+	now := time.Now()
+	entries := []domain.ReviewLog{
+		{CardID: "a", ReviewedAt: now, Rating: 3, Scheduler: "sm2"},
+		{CardID: "b", ReviewedAt: now.Add(-time.Minute), Rating: 1, Scheduler: "sm2",
+			Before: domain.CardState{Interval: 6}, After: domain.CardState{Interval: 1}},
+	}
+	for _, entry := range entries {
+		if err := logs.Append(deckPath, entry); err != nil {
+			t.Fatalf("Failed to append: %v", err)
+		}
+	}
+
+	loaded, err := logs.Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(loaded))
+	}
+	if loaded[0].CardID != "a" || loaded[1].CardID != "b" {
+		t.Errorf("Expected entries in the order they were appended, got %+v", loaded)
+	}
+	if loaded[1].Before.Interval != 6 || loaded[1].After.Interval != 1 {
+		t.Errorf("Scheduler state not preserved: %+v", loaded[1])
+	}
+}
+
+func TestMigrateBetweenJSONAndSQLite(t *testing.T) {
+	root := t.TempDir()
+	opts := []Option{WithStateDir(root, filepath.Join(root, ".state"))}
+	deckPath := filepath.Join(root, "deck.json")
+
+	files := NewFileDeckRepository(opts...)
+	deck := &domain.Deck{Name: "Deck", Cards: []domain.Card{
+		{ID: "a", Front: "Q1", Back: "A1"},
+		{ID: "b", Front: "Q2", Back: "A2"},
+	}}
+	deck.Cards[0].Interval = 6
+	if err := files.Save(deckPath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	if err := NewFileReviewLogRepository(opts...).Append(deckPath, domain.ReviewLog{CardID: "a", Rating: 4}); err != nil {
+		t.Fatalf("Failed to append: %v", err)
+	}
+
+	store := openTestStore(t, root)
+	if err := MigrateToSQLite(store, deckPath, opts...); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Migrating again replaces the deck rather than duplicating it.
+	if err := MigrateToSQLite(store, deckPath, opts...); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	loaded, err := NewSQLiteDeckRepository(store).Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load migrated deck: %v", err)
+	}
+	if loaded.Name != "Deck" || len(loaded.Cards) != 2 || loaded.CardByID("a").Interval != 6 {
+		t.Errorf("Unexpected migrated deck: %+v", loaded)
+	}
+	logs, _ := NewSQLiteReviewLogRepository(store).Load(deckPath)
+	if len(logs) != 1 || logs[0].Rating != 4 {
+		t.Errorf("Expected the review log to be migrated once, got %+v", logs)
+	}
+
+	// Changes made in the database make it back to the files.
+	loaded.CardByID("b").Interval = 3
+	if err := NewSQLiteDeckRepository(store).Save(deckPath, loaded); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Join(root, ".state")); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := MigrateToJSON(store, deckPath, opts...); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	back, err := NewFileDeckRepository(opts...).Load(deckPath)
+	if err != nil {
+		t.Fatalf("Failed to load deck: %v", err)
+	}
+	if back.CardByID("a").Interval != 6 || back.CardByID("b").Interval != 3 {
+		t.Errorf("Progress not migrated back: %+v", back.Cards)
+	}
+	logs, _ = NewFileReviewLogRepository(opts...).Load(deckPath)
+	if len(logs) != 1 || logs[0].CardID != "a" {
+		t.Errorf("Expected the review log to be migrated back, got %+v", logs)
+	}
+}
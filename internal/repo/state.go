@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StateDir decides where per-user files such as study progress and review
+// logs are kept, so that deck files only hold shared content. Decks under
+// Root get their state in a mirrored tree under Dir; any other deck keeps it
+// in a hidden .progress directory next to the deck file.
+type StateDir struct {
+	Root string
+	Dir  string
+}
+
+// Path returns the state file for deckPath with the given suffix, e.g.
+// <Dir>/spanish/vocabulary.progress.json for <Root>/spanish/vocabulary.json.
+func (s StateDir) Path(deckPath, suffix string) string {
+	name := strings.TrimSuffix(filepath.Base(deckPath), filepath.Ext(deckPath)) + suffix
+	if s.Root != "" && s.Dir != "" {
+		rel, err := filepath.Rel(s.Root, deckPath)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.Join(s.Dir, filepath.Dir(rel), name)
+		}
+	}
+	return filepath.Join(filepath.Dir(deckPath), ".progress", name)
+}
+
+type options struct {
+	state StateDir
+
+	backups     StateDir
+	keepBackups int
+}
+
+type Option func(*options)
+
+// WithStateDir keeps the state of decks under root in dir instead of next to
+// the deck files.
+func WithStateDir(root, dir string) Option {
+	return func(o *options) {
+		o.state = StateDir{Root: root, Dir: dir}
+	}
+}
+
+// WithBackups keeps the last keep versions of the files of decks under root
+// in dir, taken before they are overwritten.
+func WithBackups(root, dir string, keep int) Option {
+	return func(o *options) {
+		o.backups = StateDir{Root: root, Dir: dir}
+		o.keepBackups = keep
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+func writeStateFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0644)
+}
@@ -0,0 +1,388 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/scheduler"
+)
+
+// Problem is something wrong with a deck file, found by ValidateDeck.
+type Problem struct {
+	// Line is the line of the file the problem is on, or 0 if it isn't on
+	// a particular one.
+	Line int
+	// Card names the card the problem is with: its ID, or its position like
+	// #3 if it has none. It is empty for problems with the deck itself.
+	Card    string
+	Message string
+	// Warning marks problems that don't keep the deck from loading, but are
+	// likely mistakes.
+	Warning bool
+}
+
+// HasErrors reports whether any of the problems keeps the deck from loading
+// or studying properly.
+func HasErrors(problems []Problem) bool {
+	return slices.ContainsFunc(problems, func(p Problem) bool { return !p.Warning })
+}
+
+// ValidateDeckFile checks the deck file at path, see ValidateDeck.
+func ValidateDeckFile(path string) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateDeck(data), nil
+}
+
+// ValidateDeckContent checks the content of a loaded deck as it would be
+// written to a deck file, see ValidateDeck. It is for decks kept in the
+// database, which have no file to point to, so the problems have no line.
+func ValidateDeckContent(deck *domain.Deck) []Problem {
+	data, err := json.MarshalIndent(contentOnly(deck), "", "  ")
+	if err != nil {
+		return []Problem{{Message: err.Error()}}
+	}
+	v := &validator{data: data, noLines: true}
+	v.validate()
+	return v.problems
+}
+
+// ValidateDeck checks deck file data against the deck format described in
+// schema/deck.schema.json, and for mistakes the schema can't express, like
+// duplicate IDs or cards with the same front. Problems are in file order.
+func ValidateDeck(data []byte) []Problem {
+	v := &validator{data: data}
+	v.validate()
+	slices.SortStableFunc(v.problems, func(a, b Problem) int { return a.Line - b.Line })
+	return v.problems
+}
+
+type validator struct {
+	data []byte
+	// noLines leaves lines out of problems, for data that isn't a file.
+	noLines  bool
+	problems []Problem
+}
+
+// element is a JSON value with the offset it starts at in the file.
+type element struct {
+	offset int
+	raw    json.RawMessage
+}
+
+func (v *validator) validate() {
+	var syntax *json.SyntaxError
+	if err := json.Unmarshal(v.data, new(any)); errors.As(err, &syntax) {
+		v.add(Problem{Line: v.line(int(syntax.Offset)), Message: "invalid JSON: " + syntax.Error()})
+		return
+	} else if err != nil {
+		v.add(Problem{Message: "invalid JSON: " + err.Error()})
+		return
+	}
+
+	fields, ok := v.object(element{offset: 0, raw: v.data})
+	if !ok {
+		v.add(Problem{Line: 1, Message: "the deck must be a JSON object"})
+		return
+	}
+	v.unknownFields(fields, reflect.TypeFor[domain.Deck](), "")
+
+	// The deck without its cards, which are checked one by one below.
+	var deck struct {
+		domain.Deck
+		Cards []json.RawMessage `json:"cards"`
+	}
+	if err := json.Unmarshal(v.data, &deck); err != nil {
+		v.typeError(err, 0, "")
+		return
+	}
+
+	if f, ok := fields["version"]; ok && deck.Version > DeckVersion {
+		v.add(Problem{Line: v.line(f.offset), Message: fmt.Sprintf("%v: it is version %d, the latest supported is %d", ErrDeckTooNew, deck.Version, DeckVersion)})
+	} else if ok && deck.Version < 1 {
+		v.add(Problem{Line: v.line(f.offset), Message: fmt.Sprintf("invalid version %d", deck.Version)})
+	}
+	if strings.TrimSpace(deck.Name) == "" {
+		v.add(Problem{Line: v.fieldLine(fields, "name"), Message: "the deck has no name"})
+	}
+	if deck.Scheduler != "" && !slices.Contains(scheduler.Names(), deck.Scheduler) {
+		v.add(Problem{Line: v.fieldLine(fields, "scheduler"), Message: fmt.Sprintf("unknown scheduler %q (available: %v)", deck.Scheduler, scheduler.Names())})
+	}
+	if deck.Mode != "" && !domain.IsStudyMode(deck.Mode) {
+		v.add(Problem{Line: v.fieldLine(fields, "mode"), Message: fmt.Sprintf("unknown study mode %q (available: %v)", deck.Mode, domain.StudyModes())})
+	}
+	if limits := deck.Limits; limits != nil {
+		if (limits.NewCardsPerDay != nil && *limits.NewCardsPerDay < 0) || (limits.ReviewsPerDay != nil && *limits.ReviewsPerDay < 0) {
+			v.add(Problem{Line: v.fieldLine(fields, "limits"), Message: "limits can't be negative"})
+		}
+	}
+
+	// Note types are checked the way loading checks them, on a deck without
+	// cards. Their templates are only parsed when a card uses them.
+	noteTypesValid := true
+	if err := (&domain.Deck{NoteTypes: deck.NoteTypes}).Expand(); err != nil {
+		v.add(Problem{Line: v.fieldLine(fields, "note_types"), Message: err.Error()})
+		noteTypesValid = false
+	}
+
+	if _, ok := fields["cards"]; !ok {
+		v.add(Problem{Line: 1, Message: "the deck has no cards field"})
+		return
+	}
+	v.cards(deck.Deck, v.array(fields["cards"]), noteTypesValid)
+}
+
+// cards checks the deck's entries.
+func (v *validator) cards(deck domain.Deck, entries []element, noteTypesValid bool) {
+	type seen struct {
+		label string
+		line  int
+	}
+	ids := make(map[string]seen)
+	fronts := make(map[string]seen)
+
+	for i, entry := range entries {
+		line := v.line(entry.offset)
+		label := fmt.Sprintf("#%d", i+1)
+		fields, ok := v.object(entry)
+		if !ok {
+			v.add(Problem{Line: line, Card: label, Message: "a card must be a JSON object"})
+			continue
+		}
+
+		var card domain.Card
+		if err := json.Unmarshal(entry.raw, &card); err != nil {
+			v.typeError(err, entry.offset, label)
+			continue
+		}
+		if card.ID != "" {
+			label = card.ID
+		}
+		problem := func(message string, warning bool) {
+			v.add(Problem{Line: line, Card: label, Message: message, Warning: warning})
+		}
+		v.unknownFields(fields, reflect.TypeFor[domain.Card](), label)
+
+		if card.ID != "" {
+			if prev, ok := ids[card.ID]; ok {
+				problem("duplicate id"+v.also(prev.line), false)
+			} else {
+				ids[card.ID] = seen{label: label, line: line}
+			}
+		}
+		if slices.ContainsFunc(card.Tags, func(tag string) bool { return strings.TrimSpace(tag) == "" }) {
+			problem("empty tag", false)
+		}
+		if card.Score < 0 || card.Score > 5 {
+			problem(fmt.Sprintf("score %d is out of range 0-5", card.Score), false)
+		}
+		if card.Box < 0 || card.Box > 5 {
+			problem(fmt.Sprintf("box %d is out of range 0-5", card.Box), false)
+		}
+		if card.Interval < 0 || card.Repetitions < 0 {
+			problem("interval and repetitions can't be negative", false)
+		}
+
+		switch {
+		case card.Cloze != "":
+			if len(domain.ClozeOrdinals(card.Cloze)) == 0 {
+				problem("cloze has no {{c1::...}} deletions", false)
+			}
+
+		case card.Type != "" || card.Fields != nil:
+			if _, ok := deck.NoteType(card.Type); !ok {
+				problem(fmt.Sprintf("unknown note type %q", card.Type), false)
+				continue
+			}
+			if !noteTypesValid {
+				continue
+			}
+			// Rendering the note on its own tells whether its fields fit
+			// its type's templates.
+			single := domain.Deck{NoteTypes: deck.NoteTypes, Cards: []domain.Card{card}}
+			single.AssignCardIDs()
+			if err := single.Expand(); err != nil {
+				// The card is already named; errors about the note type
+				// are kept whole.
+				if inner := errors.Unwrap(err); inner != nil && strings.HasPrefix(err.Error(), "entry ") {
+					err = inner
+				}
+				problem(err.Error(), false)
+			}
+
+		default:
+			front, back := strings.TrimSpace(card.Front), strings.TrimSpace(card.Back)
+			if front == "" {
+				problem("missing front", false)
+			}
+			if back == "" {
+				problem("missing back", false)
+			}
+			if front == "" {
+				continue
+			}
+			if prev, ok := fronts[front]; ok {
+				problem(fmt.Sprintf("same front as card %s%s", prev.label, v.onLine(prev.line)), true)
+			} else {
+				fronts[front] = seen{label: label, line: line}
+			}
+		}
+	}
+}
+
+// object returns the fields of a JSON object with where their values start,
+// or false if e isn't an object.
+func (v *validator) object(e element) (map[string]element, bool) {
+	dec := json.NewDecoder(bytes.NewReader(e.raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, false
+	}
+	fields := make(map[string]element)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return fields, true
+		}
+		key, _ := tok.(string)
+		offset := e.offset + v.skipSpace(e.raw, int(dec.InputOffset()))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return fields, true
+		}
+		fields[key] = element{offset: offset, raw: raw}
+	}
+	return fields, true
+}
+
+// array returns the elements of a JSON array, or none if e isn't an array.
+func (v *validator) array(e element) []element {
+	dec := json.NewDecoder(bytes.NewReader(e.raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil
+	}
+	var elements []element
+	for dec.More() {
+		offset := e.offset + v.skipSpace(e.raw, int(dec.InputOffset()))
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			break
+		}
+		elements = append(elements, element{offset: offset, raw: raw})
+	}
+	return elements
+}
+
+// skipSpace returns the offset of the first byte at or after offset in data
+// that starts a value, skipping whitespace and separators.
+func (v *validator) skipSpace(data []byte, offset int) int {
+	for offset < len(data) && strings.IndexByte(" \t\r\n:,", data[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// unknownFields reports the fields of an object that aren't part of t, which
+// are most likely misspelled.
+func (v *validator) unknownFields(fields map[string]element, t reflect.Type, card string) {
+	known := jsonFields(t)
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if f := fields[name]; !known[name] {
+			v.add(Problem{Line: v.line(f.offset), Card: card, Message: fmt.Sprintf("unknown field %q", name)})
+		}
+	}
+}
+
+// jsonFields returns the names of the JSON fields of struct type t.
+func jsonFields(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// typeError reports a value of the wrong type, found decoding JSON that
+// starts at offset in the file.
+func (v *validator) typeError(err error, offset int, card string) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if i := strings.LastIndexByte(field, '.'); i >= 0 {
+			field = field[i+1:]
+		}
+		v.add(Problem{
+			Line:    v.line(offset + int(typeErr.Offset)),
+			Card:    card,
+			Message: fmt.Sprintf("%s must be %s, not %s", field, jsonType(typeErr.Type), typeErr.Value),
+		})
+		return
+	}
+	v.add(Problem{Line: v.line(offset), Card: card, Message: err.Error()})
+}
+
+// jsonType describes Go type t the way JSON would.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64:
+		return "a whole number"
+	case reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		return "an array"
+	case reflect.Map, reflect.Struct, reflect.Pointer:
+		return "an object"
+	}
+	return t.String()
+}
+
+func (v *validator) fieldLine(fields map[string]element, name string) int {
+	if f, ok := fields[name]; ok {
+		return v.line(f.offset)
+	}
+	return 1
+}
+
+// line returns the line of the file offset is on.
+func (v *validator) line(offset int) int {
+	offset = min(max(offset, 0), len(v.data))
+	return 1 + bytes.Count(v.data[:offset], []byte("\n"))
+}
+
+// also and onLine describe where something else is in the file.
+func (v *validator) also(line int) string {
+	if v.noLines {
+		return ""
+	}
+	return fmt.Sprintf(", also used on line %d", line)
+}
+
+func (v *validator) onLine(line int) string {
+	if v.noLines {
+		return ""
+	}
+	return fmt.Sprintf(" on line %d", line)
+}
+
+func (v *validator) add(p Problem) {
+	if v.noLines {
+		p.Line = 0
+	}
+	v.problems = append(v.problems, p)
+}
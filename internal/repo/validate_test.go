@@ -0,0 +1,127 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+func formatProblems(problems []Problem) string {
+	lines := make([]string, len(problems))
+	for i, p := range problems {
+		kind := "error"
+		if p.Warning {
+			kind = "warning"
+		}
+		lines[i] = fmt.Sprintf("%d %s [%s] %s", p.Line, kind, p.Card, p.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestValidateDeckValid(t *testing.T) {
+	deck := `{
+  "version": 1,
+  "name": "Valid",
+  "note_types": [{"name": "Vocab", "fields": ["word", "meaning"], "front": "{{.word}}", "back": "{{.meaning}}"}],
+  "cards": [
+    {"id": "a", "front": "Q", "back": "A", "tags": ["x"]},
+    {"front": "Q2", "back": "A2"},
+    {"id": "c", "cloze": "{{c1::Paris}} is in France"},
+    {"id": "d", "type": "Vocab", "fields": {"word": "gato", "meaning": "cat"}}
+  ]
+}`
+	if problems := ValidateDeck([]byte(deck)); len(problems) != 0 {
+		t.Errorf("Expected no problems, got:\n%s", formatProblems(problems))
+	}
+}
+
+func TestValidateDeckProblems(t *testing.T) {
+	deck := `{
+  "name": " ",
+  "mode": "guess",
+  "cards": [
+    {"id": "a", "front": "Q", "back": ""},
+    {"id": "b", "front": "Q", "back": "A", "score": 42},
+    {"id": "a", "front": "Q3", "bakc": "A"},
+    {"id": "d", "cloze": "no deletions"},
+    {"id": "e", "type": "Missing", "fields": {"word": "x"}},
+    {"id": "f", "fields": {"Front": "x", "Extra": "y"}},
+    "not a card"
+  ]
+}`
+	got := formatProblems(ValidateDeck([]byte(deck)))
+	want := strings.Join([]string{
+		`2 error [] the deck has no name`,
+		`3 error [] unknown study mode "guess" (available: [flip type choice])`,
+		`5 error [a] missing back`,
+		`6 error [b] score 42 is out of range 0-5`,
+		`6 warning [b] same front as card a on line 5`,
+		`7 error [a] unknown field "bakc"`,
+		`7 error [a] duplicate id, also used on line 5`,
+		`7 error [a] missing back`,
+		`8 error [d] cloze has no {{c1::...}} deletions`,
+		`9 error [e] unknown note type "Missing"`,
+		`10 error [f] field "Extra" is not part of note type "Basic"`,
+		`11 error [#7] a card must be a JSON object`,
+	}, "\n")
+	if got != want {
+		t.Errorf("Expected problems:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestValidateDeckInvalidJSON(t *testing.T) {
+	problems := ValidateDeck([]byte("{\n  \"name\": \"X\",\n  \"cards\": [\n}"))
+	if len(problems) != 1 || problems[0].Line != 4 || !strings.HasPrefix(problems[0].Message, "invalid JSON") {
+		t.Errorf("Expected invalid JSON on line 4, got:\n%s", formatProblems(problems))
+	}
+}
+
+func TestValidateDeckWrongTypes(t *testing.T) {
+	problems := ValidateDeck([]byte("{\n  \"name\": \"X\",\n  \"cards\": [\n    {\"id\": \"a\", \"front\": 1, \"back\": \"A\"}\n  ]\n}"))
+	if len(problems) != 1 || problems[0].Line != 4 || problems[0].Card != "#1" || problems[0].Message != "front must be a string, not number" {
+		t.Errorf("Expected a type error on line 4, got:\n%s", formatProblems(problems))
+	}
+
+	problems = ValidateDeck([]byte(`{"version": 2, "name": "X", "cards": []}`))
+	if !HasErrors(problems) || !strings.Contains(problems[0].Message, "newer than this version") {
+		t.Errorf("Expected a newer version to be reported, got:\n%s", formatProblems(problems))
+	}
+}
+
+func TestValidateDeckContent(t *testing.T) {
+	deck := &domain.Deck{Name: "Stored", Cards: []domain.Card{
+		{ID: "a", Front: "Q", Back: "A", Score: 4},
+		{ID: "b", Front: "Q", Back: " "},
+	}}
+	got := formatProblems(ValidateDeckContent(deck))
+	want := strings.Join([]string{
+		`0 error [b] missing back`,
+		`0 warning [b] same front as card a`,
+	}, "\n")
+	if got != want {
+		t.Errorf("Expected problems:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	if HasErrors([]Problem{{Message: "same front", Warning: true}}) {
+		t.Error("Expected warnings alone not to count as errors")
+	}
+	if !HasErrors([]Problem{{Message: "same front", Warning: true}, {Message: "missing back"}}) {
+		t.Error("Expected an error to be found")
+	}
+}
+
+func TestValidateDeckProgressAddedAfterSplit(t *testing.T) {
+	problems := ValidateDeck([]byte(`{"name": "X", "cards": [{"id": "a", "front": "Q", "back": "A", "interval": 3, "suspended": true, "lapses": 2}]}`))
+	got := formatProblems(problems)
+	want := strings.Join([]string{
+		`1 error [a] unknown field "lapses"`,
+		`1 error [a] unknown field "suspended"`,
+	}, "\n")
+	if got != want {
+		t.Errorf("Expected problems:\n%s\ngot:\n%s", want, got)
+	}
+}
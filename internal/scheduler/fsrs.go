@@ -0,0 +1,217 @@
+package scheduler
+
+import (
+	"math"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+// DefaultFSRSWeights are the FSRS-5 default parameters.
+var DefaultFSRSWeights = [19]float64{
+	0.40255, 1.18385, 3.173, 15.69105, 7.1949, 0.5345, 1.4604, 0.0046, 1.54575,
+	0.1192, 1.01925, 1.9395, 0.11, 0.29605, 2.2698, 0.2315, 2.9898, 0.51655, 0.6621,
+}
+
+const (
+	fsrsDecay  = -0.5
+	fsrsFactor = 19.0 / 81.0
+
+	DefaultRequestRetention = 0.9
+	DefaultMaximumInterval  = 36500
+)
+
+// FSRS grades, mapped from the 1-5 score by fsrsGrade.
+const (
+	gradeAgain = 1
+	gradeHard  = 2
+	gradeGood  = 3
+	gradeEasy  = 4
+)
+
+// FSRS implements the Free Spaced Repetition Scheduler (version 5). It models
+// each card's memory with a stability, the number of days until recall drops
+// to 90%, and a difficulty between 1 and 10.
+type FSRS struct {
+	Weights          [19]float64
+	RequestRetention float64
+	MaximumInterval  int
+}
+
+func NewFSRS() *FSRS {
+	return &FSRS{
+		Weights:          DefaultFSRSWeights,
+		RequestRetention: DefaultRequestRetention,
+		MaximumInterval:  DefaultMaximumInterval,
+	}
+}
+
+func (f *FSRS) Name() string {
+	return FSRSName
+}
+
+func (f *FSRS) Rate(card *domain.Card, score int, now time.Time) {
+	grade := fsrsGrade(score)
+	stability, difficulty := f.memoryState(card)
+
+	switch {
+	case stability == 0:
+		stability = f.initialStability(grade)
+		difficulty = clampDifficulty(f.initialDifficulty(grade))
+	case now.Sub(card.LastReview) < day:
+		stability = f.shortTermStability(stability, grade)
+		difficulty = f.nextDifficulty(difficulty, grade)
+	default:
+		r := f.retrievability(card, now)
+		if grade == gradeAgain {
+			stability = f.forgetStability(difficulty, stability, r)
+		} else {
+			stability = f.recallStability(difficulty, stability, r, grade)
+		}
+		difficulty = f.nextDifficulty(difficulty, grade)
+	}
+
+	if grade == gradeAgain {
+		card.Repetitions = 0
+	} else {
+		card.Repetitions++
+	}
+
+	card.Stability = stability
+	card.Difficulty = difficulty
+	card.Interval = f.nextInterval(stability)
+	card.Due = now.Add(daysToDuration(card.Interval))
+}
+
+// RateShortTerm moves the card's memory state by the short-term formula and
+// reschedules it from there. Repetitions are left alone: the repeat is part of
+// the review it follows.
+func (f *FSRS) RateShortTerm(card *domain.Card, score int, now time.Time) {
+	stability, difficulty := f.memoryState(card)
+	if stability == 0 {
+		f.Rate(card, score, now)
+		return
+	}
+	grade := fsrsGrade(score)
+	card.Stability = f.shortTermStability(stability, grade)
+	card.Difficulty = f.nextDifficulty(difficulty, grade)
+	card.Interval = f.nextInterval(card.Stability)
+	card.Due = now.Add(daysToDuration(card.Interval))
+}
+
+func (f *FSRS) NextDue(card domain.Card, score int, now time.Time) time.Time {
+	return previewDue(f, card, score, now)
+}
+
+// Less puts due cards first, the ones most likely to have been forgotten
+// first.
+func (f *FSRS) Less(a, b *domain.Card, now time.Time) bool {
+	if less, ok := lessByDue(a, b, now); ok {
+		return less
+	}
+	aR, bR := f.retrievability(a, now), f.retrievability(b, now)
+	if aR != bR {
+		return aR < bR
+	}
+	return a.LastReview.Before(b.LastReview)
+}
+
+// memoryState returns the card's stability and difficulty. Cards that were
+// reviewed with another scheduler get an estimate from their interval so
+// switching algorithms does not reset progress.
+func (f *FSRS) memoryState(card *domain.Card) (float64, float64) {
+	if card.Stability > 0 {
+		return card.Stability, clampDifficulty(card.Difficulty)
+	}
+	if card.LastReview.IsZero() || card.Interval == 0 {
+		return 0, 0
+	}
+	difficulty := f.initialDifficulty(gradeGood)
+	if card.EaseFactor > 0 {
+		difficulty = 11 - 4*(card.EaseFactor-minEaseFactor)
+	}
+	return float64(card.Interval), clampDifficulty(difficulty)
+}
+
+// retrievability is the probability of recalling the card at now. Cards that
+// have never been reviewed have nothing to recall.
+func (f *FSRS) retrievability(card *domain.Card, now time.Time) float64 {
+	stability, _ := f.memoryState(card)
+	if stability == 0 {
+		return 0
+	}
+	elapsed := max(now.Sub(card.LastReview).Hours()/24, 0)
+	return math.Pow(1+fsrsFactor*elapsed/stability, fsrsDecay)
+}
+
+func (f *FSRS) initialStability(grade int) float64 {
+	return max(f.Weights[grade-1], 0.1)
+}
+
+func (f *FSRS) initialDifficulty(grade int) float64 {
+	w := f.Weights
+	return w[4] - math.Exp(w[5]*float64(grade-1)) + 1
+}
+
+func (f *FSRS) nextDifficulty(difficulty float64, grade int) float64 {
+	w := f.Weights
+	delta := -w[6] * float64(grade-3)
+	next := difficulty + delta*(10-difficulty)/9
+	return clampDifficulty(w[7]*f.initialDifficulty(gradeEasy) + (1-w[7])*next)
+}
+
+func (f *FSRS) recallStability(difficulty, stability, r float64, grade int) float64 {
+	w := f.Weights
+	hardPenalty, easyBonus := 1.0, 1.0
+	if grade == gradeHard {
+		hardPenalty = w[15]
+	}
+	if grade == gradeEasy {
+		easyBonus = w[16]
+	}
+	return stability * (1 + math.Exp(w[8])*
+		(11-difficulty)*
+		math.Pow(stability, -w[9])*
+		(math.Exp((1-r)*w[10])-1)*
+		hardPenalty*
+		easyBonus)
+}
+
+func (f *FSRS) forgetStability(difficulty, stability, r float64) float64 {
+	w := f.Weights
+	next := w[11] *
+		math.Pow(difficulty, -w[12]) *
+		(math.Pow(stability+1, w[13]) - 1) *
+		math.Exp((1-r)*w[14])
+	return min(next, stability)
+}
+
+func (f *FSRS) shortTermStability(stability float64, grade int) float64 {
+	w := f.Weights
+	return stability * math.Exp(w[17]*(float64(grade)-3+w[18]))
+}
+
+func (f *FSRS) nextInterval(stability float64) int {
+	interval := stability / fsrsFactor * (math.Pow(f.RequestRetention, 1/fsrsDecay) - 1)
+	return min(max(int(math.Round(interval)), 1), f.MaximumInterval)
+}
+
+// fsrsGrade maps a 1-5 score onto FSRS's Again/Hard/Good/Easy grades. Scores
+// below PassingScore are Again, so FSRS forgets a card whenever the rest of
+// spacdr counts a lapse, and the lowest passing score is Hard.
+func fsrsGrade(score int) int {
+	switch {
+	case score < PassingScore:
+		return gradeAgain
+	case score == PassingScore:
+		return gradeHard
+	case score >= MaxScore:
+		return gradeEasy
+	default:
+		return gradeGood
+	}
+}
+
+func clampDifficulty(d float64) float64 {
+	return min(max(d, 1), 10)
+}
@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+// DefaultLeitnerIntervals are the review intervals in days for boxes 1 to 5.
+var DefaultLeitnerIntervals = []int{1, 2, 4, 8, 16}
+
+// Leitner implements the classic box system: a passing score moves a card up
+// one box, anything else sends it back to the first box. Each box has a fixed
+// review interval.
+type Leitner struct {
+	Intervals []int
+}
+
+func NewLeitner() *Leitner {
+	return &Leitner{Intervals: DefaultLeitnerIntervals}
+}
+
+func (l *Leitner) Name() string {
+	return LeitnerName
+}
+
+func (l *Leitner) Rate(card *domain.Card, score int, now time.Time) {
+	box := l.currentBox(card)
+
+	if score >= PassingScore {
+		box++
+		card.Repetitions++
+	} else {
+		box = 1
+		card.Repetitions = 0
+	}
+	box = min(box, len(l.Intervals))
+
+	card.Box = box
+	card.Interval = l.Intervals[box-1]
+	card.Due = now.Add(daysToDuration(card.Interval))
+}
+
+func (l *Leitner) NextDue(card domain.Card, score int, now time.Time) time.Time {
+	return previewDue(l, card, score, now)
+}
+
+// Less puts due cards first, lowest box first so the weakest cards are seen
+// before the ones that are nearly learned.
+func (l *Leitner) Less(a, b *domain.Card, now time.Time) bool {
+	if less, ok := lessByDue(a, b, now); ok {
+		return less
+	}
+	if aBox, bBox := l.currentBox(a), l.currentBox(b); aBox != bBox {
+		return aBox < bBox
+	}
+	return a.LastReview.Before(b.LastReview)
+}
+
+// currentBox returns the card's box, placing cards that were studied with
+// another scheduler according to their streak of successful reviews.
+func (l *Leitner) currentBox(card *domain.Card) int {
+	if card.Box > 0 {
+		return min(card.Box, len(l.Intervals))
+	}
+	return min(card.Repetitions+1, len(l.Intervals))
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+const (
+	MinScore = 1
+	MaxScore = 5
+
+	// PassingScore is the lowest score that counts as remembering a card.
+	PassingScore = 3
+
+	day = 24 * time.Hour
+)
+
+// ShortTermScheduler is implemented by schedulers that model reviews made
+// on the same day as the previous one, like the repeats of a card at its
+// learning steps. Other schedulers leave such repeats out.
+type ShortTermScheduler interface {
+	// RateShortTerm updates the card for a repeat at now of a rating made
+	// earlier that day.
+	RateShortTerm(card *domain.Card, score int, now time.Time)
+}
+
+// Scheduler decides when cards come back for review. Scores are always on
+// spacdr's 1-5 scale; each implementation maps them onto its own grades.
+type Scheduler interface {
+	// Name is the identifier used in config files and decks.
+	Name() string
+	// Rate updates the card's scheduling state for a review at now.
+	Rate(card *domain.Card, score int, now time.Time)
+	// NextDue returns when the card would be due if it were rated with score,
+	// without modifying it.
+	NextDue(card domain.Card, score int, now time.Time) time.Time
+	// Less reports whether a should be studied before b.
+	Less(a, b *domain.Card, now time.Time) bool
+}
+
+const (
+	SM2Name     = "sm2"
+	FSRSName    = "fsrs"
+	LeitnerName = "leitner"
+
+	DefaultName = SM2Name
+)
+
+// Names lists the schedulers that can be selected by name.
+func Names() []string {
+	return []string{SM2Name, FSRSName, LeitnerName}
+}
+
+// New returns the scheduler registered under name. An empty name selects the
+// default scheduler.
+func New(name string) (Scheduler, error) {
+	switch name {
+	case "", SM2Name:
+		return NewSM2(), nil
+	case FSRSName:
+		return NewFSRS(), nil
+	case LeitnerName:
+		return NewLeitner(), nil
+	default:
+		return nil, fmt.Errorf("unknown scheduler %q (available: %v)", name, Names())
+	}
+}
+
+// Sort orders cards in place so that the ones s wants studied first come first.
+func Sort(s Scheduler, cards []domain.Card, now time.Time) {
+	sort.SliceStable(cards, func(i, j int) bool {
+		return s.Less(&cards[i], &cards[j], now)
+	})
+}
+
+func previewDue(s Scheduler, card domain.Card, score int, now time.Time) time.Time {
+	s.Rate(&card, score, now)
+	return card.Due
+}
+
+// lessByDue puts due cards before cards scheduled for later and orders the
+// later ones by due date. The second return value is false when both cards
+// are due and the caller needs its own tie-breaker.
+func lessByDue(a, b *domain.Card, now time.Time) (less bool, decided bool) {
+	aDue, bDue := a.IsDue(now), b.IsDue(now)
+	if aDue != bDue {
+		return aDue, true
+	}
+	if !aDue {
+		return a.Due.Before(b.Due), true
+	}
+	return false, false
+}
+
+func daysToDuration(days int) time.Duration {
+	return time.Duration(days) * day
+}
@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+func TestNew(t *testing.T) {
+	for _, name := range Names() {
+		s, err := New(name)
+		if err != nil {
+			t.Fatalf("New(%q) failed: %v", name, err)
+		}
+		if s.Name() != name {
+			t.Errorf("New(%q) returned scheduler named %q", name, s.Name())
+		}
+	}
+
+	s, err := New("")
+	if err != nil {
+		t.Fatalf("New(\"\") failed: %v", err)
+	}
+	if s.Name() != DefaultName {
+		t.Errorf("Expected default scheduler %q, got %q", DefaultName, s.Name())
+	}
+
+	if _, err := New("anki"); err == nil {
+		t.Error("Expected error for unknown scheduler, got nil")
+	}
+}
+
+func TestNextDueDoesNotModifyCard(t *testing.T) {
+	now := time.Now()
+	for _, name := range Names() {
+		s, _ := New(name)
+		card := domain.Card{Front: "Q", Back: "A"}
+
+		due := s.NextDue(card, 5, now)
+		if !due.After(now) {
+			t.Errorf("%s: expected next due after now, got %v", name, due)
+		}
+		if !card.Due.IsZero() || card.Interval != 0 {
+			t.Errorf("%s: NextDue modified the card", name)
+		}
+
+		s.Rate(&card, 5, now)
+		if !card.Due.Equal(due) {
+			t.Errorf("%s: NextDue predicted %v but Rate scheduled %v", name, due, card.Due)
+		}
+	}
+}
+
+func TestSM2Intervals(t *testing.T) {
+	s := NewSM2()
+	now := time.Now()
+	card := domain.Card{}
+
+	for i, want := range []int{1, 6, 16} {
+		s.Rate(&card, 5, now)
+		if card.Interval != want {
+			t.Errorf("Review %d: expected interval %d, got %d", i, want, card.Interval)
+		}
+	}
+
+	s.Rate(&card, 2, now)
+	if card.Interval != 1 || card.Repetitions != 0 {
+		t.Errorf("Expected lapse to reset to interval 1, got interval %d, repetitions %d", card.Interval, card.Repetitions)
+	}
+}
+
+func TestFSRSFirstReview(t *testing.T) {
+	f := NewFSRS()
+	now := time.Now()
+
+	for score, grade := range map[int]int{1: gradeAgain, 2: gradeAgain, 3: gradeHard, 4: gradeGood, 5: gradeEasy} {
+		card := domain.Card{}
+		f.Rate(&card, score, now)
+
+		if card.Stability != DefaultFSRSWeights[grade-1] {
+			t.Errorf("Score %d: expected stability %f, got %f", score, DefaultFSRSWeights[grade-1], card.Stability)
+		}
+		if card.Difficulty < 1 || card.Difficulty > 10 {
+			t.Errorf("Score %d: difficulty %f out of range", score, card.Difficulty)
+		}
+	}
+
+	easy, hard := domain.Card{}, domain.Card{}
+	f.Rate(&easy, 5, now)
+	f.Rate(&hard, 2, now)
+	if easy.Difficulty >= hard.Difficulty {
+		t.Errorf("Expected easy card to be less difficult than hard card, got %f vs %f", easy.Difficulty, hard.Difficulty)
+	}
+	if easy.Interval != 16 {
+		t.Errorf("Expected first easy interval of 16 days, got %d", easy.Interval)
+	}
+}
+
+func TestFSRSGrowsAndShrinksStability(t *testing.T) {
+	f := NewFSRS()
+	now := time.Now()
+	card := domain.Card{}
+
+	f.Rate(&card, 3, now)
+	now = now.Add(daysToDuration(card.Interval))
+	stability := card.Stability
+	card.LastReview = now.Add(-daysToDuration(card.Interval))
+
+	f.Rate(&card, 3, now)
+	if card.Stability <= stability {
+		t.Errorf("Expected stability to grow after a successful review, got %f -> %f", stability, card.Stability)
+	}
+
+	stability = card.Stability
+	card.LastReview = now
+	now = now.Add(daysToDuration(card.Interval))
+	f.Rate(&card, 1, now)
+	if card.Stability >= stability {
+		t.Errorf("Expected stability to drop after a lapse, got %f -> %f", stability, card.Stability)
+	}
+	if card.Repetitions != 0 {
+		t.Errorf("Expected repetitions reset after a lapse, got %d", card.Repetitions)
+	}
+}
+
+func TestSchedulersAgreeOnPassingScore(t *testing.T) {
+	for _, name := range Names() {
+		s, _ := New(name)
+		now := time.Now()
+		card := domain.Card{}
+		for range 3 {
+			s.Rate(&card, 4, now)
+			card.LastReview = now
+			now = now.Add(daysToDuration(card.Interval))
+		}
+
+		s.Rate(&card, PassingScore-1, now)
+		if card.Repetitions != 0 {
+			t.Errorf("%s: expected score %d to count as forgotten, got %d repetitions", name, PassingScore-1, card.Repetitions)
+		}
+		card.LastReview = now
+		now = now.Add(daysToDuration(card.Interval))
+		s.Rate(&card, PassingScore, now)
+		if card.Repetitions != 1 {
+			t.Errorf("%s: expected score %d to count as remembered, got %d repetitions", name, PassingScore, card.Repetitions)
+		}
+	}
+}
+
+func TestFSRSUsesIntervalFromOtherSchedulers(t *testing.T) {
+	f := NewFSRS()
+	now := time.Now()
+	card := domain.Card{Interval: 30, EaseFactor: 2.5, Repetitions: 4, LastReview: now.Add(-30 * day)}
+
+	f.Rate(&card, 3, now)
+	if card.Interval <= 30 {
+		t.Errorf("Expected interval to grow past 30 days, got %d", card.Interval)
+	}
+}
+
+func TestLeitnerBoxes(t *testing.T) {
+	l := NewLeitner()
+	now := time.Now()
+	card := domain.Card{}
+
+	for i, wantBox := range []int{2, 3, 4, 5, 5} {
+		l.Rate(&card, 4, now)
+		if card.Box != wantBox {
+			t.Errorf("Review %d: expected box %d, got %d", i, wantBox, card.Box)
+		}
+		if card.Interval != DefaultLeitnerIntervals[wantBox-1] {
+			t.Errorf("Review %d: expected interval %d, got %d", i, DefaultLeitnerIntervals[wantBox-1], card.Interval)
+		}
+	}
+
+	l.Rate(&card, 2, now)
+	if card.Box != 1 {
+		t.Errorf("Expected failed card back in box 1, got %d", card.Box)
+	}
+}
+
+func TestSortDueCardsFirst(t *testing.T) {
+	now := time.Now()
+	for _, name := range Names() {
+		s, _ := New(name)
+		cards := []domain.Card{
+			{Front: "Later", Due: now.Add(48 * time.Hour), LastReview: now},
+			{Front: "Soon", Due: now.Add(24 * time.Hour), LastReview: now},
+			{Front: "Overdue", Due: now.Add(-time.Hour), LastReview: now.Add(-7 * day), Interval: 6, Repetitions: 2},
+		}
+
+		Sort(s, cards, now)
+
+		for i, front := range []string{"Overdue", "Soon", "Later"} {
+			if cards[i].Front != front {
+				t.Errorf("%s: position %d expected %s, got %s", name, i, front, cards[i].Front)
+			}
+		}
+	}
+}
+
+func TestLeitnerLessPrefersLowerBox(t *testing.T) {
+	l := NewLeitner()
+	now := time.Now()
+	low := domain.Card{Box: 1, Due: now.Add(-time.Hour)}
+	high := domain.Card{Box: 4, Due: now.Add(-time.Hour)}
+
+	if !l.Less(&low, &high, now) || l.Less(&high, &low, now) {
+		t.Error("Expected the card in the lower box to come first")
+	}
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"math"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+const (
+	defaultEaseFactor = 2.5
+	minEaseFactor     = 1.3
+)
+
+// SM2 implements the SuperMemo-2 algorithm. The 1-5 score is used directly as
+// SM-2's response quality; anything below 3 is a lapse and restarts the
+// repetition sequence.
+type SM2 struct{}
+
+func NewSM2() *SM2 {
+	return &SM2{}
+}
+
+func (s *SM2) Name() string {
+	return SM2Name
+}
+
+func (s *SM2) Rate(card *domain.Card, score int, now time.Time) {
+	ease := card.EaseFactor
+	if ease == 0 {
+		ease = defaultEaseFactor
+	}
+
+	if score < PassingScore {
+		card.Repetitions = 0
+		card.Interval = 1
+	} else {
+		switch card.Repetitions {
+		case 0:
+			card.Interval = 1
+		case 1:
+			card.Interval = 6
+		default:
+			card.Interval = int(math.Round(float64(card.Interval) * ease))
+		}
+		card.Repetitions++
+	}
+
+	q := float64(MaxScore - score)
+	ease += 0.1 - q*(0.08+q*0.02)
+	if ease < minEaseFactor {
+		ease = minEaseFactor
+	}
+
+	card.EaseFactor = ease
+	card.Due = now.Add(daysToDuration(card.Interval))
+}
+
+func (s *SM2) NextDue(card domain.Card, score int, now time.Time) time.Time {
+	return previewDue(s, card, score, now)
+}
+
+// Less puts due cards first, ordered by score and then by how long ago they
+// were reviewed. Cards scheduled for later follow in order of their due date.
+func (s *SM2) Less(a, b *domain.Card, now time.Time) bool {
+	if less, ok := lessByDue(a, b, now); ok {
+		return less
+	}
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	return a.LastReview.Before(b.LastReview)
+}
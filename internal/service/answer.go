@@ -0,0 +1,165 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type DiffOp int
+
+const (
+	// DiffEqual is text the answer got right.
+	DiffEqual DiffOp = iota
+	// DiffMissing is text of the expected answer that wasn't typed.
+	DiffMissing
+	// DiffExtra is typed text that isn't part of the expected answer.
+	DiffExtra
+)
+
+type DiffSegment struct {
+	Op   DiffOp
+	Text string
+}
+
+// AnswerResult is the outcome of comparing a typed answer to a card's back.
+type AnswerResult struct {
+	// Correct means the answer matches, ignoring case, diacritics and
+	// whitespace.
+	Correct bool
+	// NearMiss means the answer is off by a typo or two.
+	NearMiss bool
+	// Distance is the edit distance between the normalized answers.
+	Distance int
+	// Diff shows the typed answer against the expected one.
+	Diff []DiffSegment
+	// SuggestedScore is the 1-5 rating the answer deserves.
+	SuggestedScore int
+}
+
+// GradeAnswer compares what the user typed with the expected answer. Answers
+// within roughly one typo per five characters count as near misses.
+func GradeAnswer(expected, typed string) AnswerResult {
+	want := []rune(normalizeAnswer(expected))
+	got := []rune(normalizeAnswer(typed))
+
+	result := AnswerResult{
+		Distance: levenshtein(want, got),
+		Diff:     diffAnswer([]rune(collapseSpaces(expected)), []rune(collapseSpaces(typed))),
+	}
+
+	switch {
+	case result.Distance == 0:
+		result.Correct = true
+		result.SuggestedScore = 4
+	case len(got) > 0 && result.Distance <= max(1, len(want)/5):
+		result.NearMiss = true
+		result.SuggestedScore = 3
+	default:
+		result.SuggestedScore = 1
+	}
+	return result
+}
+
+// normalizeAnswer folds case and diacritics and collapses whitespace, so that
+// "  Éclair " and "eclair" compare equal.
+func normalizeAnswer(s string) string {
+	var b strings.Builder
+	for _, r := range collapseSpaces(s) {
+		b.WriteRune(foldRune(r))
+	}
+	return b.String()
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// foldRune lower-cases r and strips any accents from it.
+func foldRune(r rune) rune {
+	for _, base := range norm.NFD.String(string(r)) {
+		if !unicode.Is(unicode.Mn, base) {
+			return unicode.ToLower(base)
+		}
+	}
+	return unicode.ToLower(r)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// diffAnswer aligns typed against expected with a minimal edit script,
+// comparing runes the same way normalizeAnswer does. A wrongly typed
+// character comes right before the one that should have been there.
+func diffAnswer(expected, typed []rune) []DiffSegment {
+	n, m := len(expected), len(typed)
+	dist := make([][]int, n+1)
+	for i := range dist {
+		dist[i] = make([]int, m+1)
+		dist[i][0] = i
+	}
+	for j := 0; j <= m; j++ {
+		dist[0][j] = j
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			cost := 1
+			if foldRune(expected[i-1]) == foldRune(typed[j-1]) {
+				cost = 0
+			}
+			dist[i][j] = min(dist[i-1][j]+1, dist[i][j-1]+1, dist[i-1][j-1]+cost)
+		}
+	}
+
+	// Walk back from the end, collecting operations in reverse.
+	var ops []DiffSegment
+	i, j := n, m
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && foldRune(expected[i-1]) == foldRune(typed[j-1]) && dist[i][j] == dist[i-1][j-1]:
+			ops = append(ops, DiffSegment{Op: DiffEqual, Text: string(typed[j-1])})
+			i, j = i-1, j-1
+		case i > 0 && j > 0 && dist[i][j] == dist[i-1][j-1]+1:
+			ops = append(ops, DiffSegment{Op: DiffMissing, Text: string(expected[i-1])})
+			ops = append(ops, DiffSegment{Op: DiffExtra, Text: string(typed[j-1])})
+			i, j = i-1, j-1
+		case j > 0 && dist[i][j] == dist[i][j-1]+1:
+			ops = append(ops, DiffSegment{Op: DiffExtra, Text: string(typed[j-1])})
+			j--
+		default:
+			ops = append(ops, DiffSegment{Op: DiffMissing, Text: string(expected[i-1])})
+			i--
+		}
+	}
+
+	// Reverse and merge neighbouring segments with the same operation.
+	var diff []DiffSegment
+	for k := len(ops) - 1; k >= 0; k-- {
+		op := ops[k]
+		if last := len(diff) - 1; last >= 0 && diff[last].Op == op.Op {
+			diff[last].Text += op.Text
+			continue
+		}
+		diff = append(diff, op)
+	}
+	return diff
+}
@@ -0,0 +1,101 @@
+package service
+
+import "testing"
+
+func TestGradeAnswerCorrect(t *testing.T) {
+	cases := []struct{ expected, typed string }{
+		{"Hello", "hello"},
+		{"¿Cómo estás?", "¿como estas?"},
+		{"How are  you?", "  how are you? "},
+		{"Éclair", "ECLAIR"},
+	}
+	for _, c := range cases {
+		result := GradeAnswer(c.expected, c.typed)
+		if !result.Correct {
+			t.Errorf("Expected %q to match %q, got distance %d", c.typed, c.expected, result.Distance)
+		}
+		if result.SuggestedScore != 4 {
+			t.Errorf("Expected suggested score 4 for %q, got %d", c.typed, result.SuggestedScore)
+		}
+	}
+}
+
+func TestGradeAnswerNearMiss(t *testing.T) {
+	result := GradeAnswer("goroutine", "gorutine")
+	if result.Correct || !result.NearMiss {
+		t.Errorf("Expected near miss, got %+v", result)
+	}
+	if result.Distance != 1 {
+		t.Errorf("Expected distance 1, got %d", result.Distance)
+	}
+	if result.SuggestedScore != 3 {
+		t.Errorf("Expected suggested score 3, got %d", result.SuggestedScore)
+	}
+}
+
+func TestGradeAnswerWrong(t *testing.T) {
+	for _, typed := range []string{"channel", "", "x"} {
+		result := GradeAnswer("goroutine", typed)
+		if result.Correct || result.NearMiss {
+			t.Errorf("Expected %q to be wrong, got %+v", typed, result)
+		}
+		if result.SuggestedScore != 1 {
+			t.Errorf("Expected suggested score 1 for %q, got %d", typed, result.SuggestedScore)
+		}
+	}
+}
+
+func TestGradeAnswerDiff(t *testing.T) {
+	result := GradeAnswer("mutex", "mutax")
+
+	expected := []DiffSegment{
+		{Op: DiffEqual, Text: "mut"},
+		{Op: DiffExtra, Text: "a"},
+		{Op: DiffMissing, Text: "e"},
+		{Op: DiffEqual, Text: "x"},
+	}
+	if len(result.Diff) != len(expected) {
+		t.Fatalf("Expected diff %+v, got %+v", expected, result.Diff)
+	}
+	for i := range expected {
+		if result.Diff[i] != expected[i] {
+			t.Errorf("Segment %d: expected %+v, got %+v", i, expected[i], result.Diff[i])
+		}
+	}
+}
+
+func TestGradeAnswerDiffMissingAndExtra(t *testing.T) {
+	result := GradeAnswer("select", "selects")
+	last := result.Diff[len(result.Diff)-1]
+	if last.Op != DiffExtra || last.Text != "s" {
+		t.Errorf("Expected trailing extra 's', got %+v", result.Diff)
+	}
+
+	result = GradeAnswer("select", "selct")
+	found := false
+	for _, seg := range result.Diff {
+		if seg.Op == DiffMissing && seg.Text == "e" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected missing 'e', got %+v", result.Diff)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+	}
+	for _, c := range cases {
+		if got := levenshtein([]rune(c.a), []rune(c.b)); got != c.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
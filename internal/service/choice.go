@@ -0,0 +1,137 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+const (
+	ChoiceOptions = 4
+
+	// Scores given for picking the right or a wrong option.
+	ChoiceCorrectScore = 4
+	ChoiceWrongScore   = 1
+
+	// Distractors are drawn at random from up to distractorPool answers about
+	// as similar as the third most similar one, so the same card doesn't
+	// always get the same options.
+	distractorPool  = 8
+	distractorSlack = 0.5
+)
+
+// Choice is a multiple-choice question for a card.
+type Choice struct {
+	Options []string
+	// Correct is the index of the card's own answer in Options.
+	Correct int
+}
+
+// Score returns the rating for picking the option at index.
+func (c Choice) Score(index int) int {
+	if index == c.Correct {
+		return ChoiceCorrectScore
+	}
+	return ChoiceWrongScore
+}
+
+// BuildChoice offers the card's answer together with up to three answers of
+// other cards in the deck, preferring ones that look like the right answer:
+// numbers for numbers, short words for short words, sentences for sentences.
+func BuildChoice(deck *domain.Deck, cardID string, rng *rand.Rand) (Choice, error) {
+	card := deck.CardByID(cardID)
+	if card == nil {
+		return Choice{}, fmt.Errorf("%w: %s", ErrCardNotFound, cardID)
+	}
+
+	answer := strings.TrimSpace(card.Answer())
+	seen := map[string]bool{normalizeAnswer(answer): true}
+
+	var candidates []string
+	for i := range deck.Cards {
+		// Other deletions of a cloze note are visible on the card itself.
+		if card.Note != "" && deck.Cards[i].Note == card.Note {
+			continue
+		}
+		back := strings.TrimSpace(deck.Cards[i].Answer())
+		key := normalizeAnswer(back)
+		if back == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		candidates = append(candidates, back)
+	}
+
+	rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	distances := make(map[string]float64, len(candidates))
+	for _, c := range candidates {
+		distances[c] = answerDistance(answer, c)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return distances[candidates[i]] < distances[candidates[j]]
+	})
+
+	pool := candidates[:min(len(candidates), distractorPool)]
+	if len(pool) >= ChoiceOptions {
+		limit := distances[pool[ChoiceOptions-2]] + distractorSlack
+		for len(pool) > ChoiceOptions-1 && distances[pool[len(pool)-1]] > limit {
+			pool = pool[:len(pool)-1]
+		}
+	}
+	rng.Shuffle(len(pool), func(i, j int) {
+		pool[i], pool[j] = pool[j], pool[i]
+	})
+	distractors := pool[:min(len(pool), ChoiceOptions-1)]
+
+	correct := rng.IntN(len(distractors) + 1)
+	options := make([]string, 0, len(distractors)+1)
+	options = append(options, distractors[:correct]...)
+	options = append(options, answer)
+	options = append(options, distractors[correct:]...)
+
+	return Choice{Options: options, Correct: correct}, nil
+}
+
+type answerKind int
+
+const (
+	kindNumber answerKind = iota
+	kindWord
+	kindPhrase
+)
+
+func kindOf(s string) answerKind {
+	isNumber := true
+	for _, r := range s {
+		if !unicode.IsDigit(r) && !strings.ContainsRune(".,-+%/ ", r) {
+			isNumber = false
+			break
+		}
+	}
+	switch {
+	case isNumber:
+		return kindNumber
+	case strings.ContainsFunc(s, unicode.IsSpace):
+		return kindPhrase
+	default:
+		return kindWord
+	}
+}
+
+// answerDistance scores how different two answers look: lengths are compared
+// on a log scale, and answers of a different kind are pushed further apart.
+func answerDistance(a, b string) float64 {
+	la, lb := float64(len([]rune(a))), float64(len([]rune(b)))
+	d := math.Abs(math.Log1p(la) - math.Log1p(lb))
+	if kindOf(a) != kindOf(b) {
+		d += 2
+	}
+	return d
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+func createChoiceDeck() *domain.Deck {
+	return &domain.Deck{
+		Name: "Choice Deck",
+		Cards: []domain.Card{
+			{ID: "year", Front: "Go 1.0 release year?", Back: "2012"},
+			{ID: "cores", Front: "Default GOMAXPROCS on 8 cores?", Back: "8"},
+			{ID: "port", Front: "Default pprof port?", Back: "6060"},
+			{ID: "size", Front: "Initial goroutine stack in KB?", Back: "2"},
+			{ID: "chan", Front: "Typed conduit between goroutines?", Back: "channel"},
+			{ID: "defer", Front: "Runs a call when the function returns?", Back: "defer"},
+			{ID: "iface", Front: "What is an interface?", Back: "A set of method signatures"},
+			{ID: "dup", Front: "Year Go 1.0 shipped?", Back: "2012"},
+		},
+	}
+}
+
+func TestBuildChoice(t *testing.T) {
+	deck := createChoiceDeck()
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	for range 20 {
+		choice, err := BuildChoice(deck, "year", rng)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(choice.Options) != ChoiceOptions {
+			t.Fatalf("Expected %d options, got %d", ChoiceOptions, len(choice.Options))
+		}
+		if choice.Options[choice.Correct] != "2012" {
+			t.Errorf("Expected correct option '2012', got %q", choice.Options[choice.Correct])
+		}
+
+		seen := make(map[string]bool)
+		for i, option := range choice.Options {
+			if seen[option] {
+				t.Errorf("Expected distinct options, got %v", choice.Options)
+			}
+			seen[option] = true
+			if i != choice.Correct && option == "2012" {
+				t.Errorf("Expected the answer only once, got %v", choice.Options)
+			}
+		}
+	}
+}
+
+func TestBuildChoicePrefersSimilarAnswers(t *testing.T) {
+	deck := createChoiceDeck()
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	for range 20 {
+		choice, err := BuildChoice(deck, "year", rng)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, option := range choice.Options {
+			if option == "A set of method signatures" {
+				t.Errorf("Expected numeric distractors for a numeric answer, got %v", choice.Options)
+			}
+		}
+	}
+}
+
+func TestBuildChoiceSmallDeck(t *testing.T) {
+	deck := &domain.Deck{Cards: []domain.Card{
+		{ID: "a", Front: "A", Back: "alpha"},
+		{ID: "b", Front: "B", Back: "beta"},
+	}}
+
+	choice, err := BuildChoice(deck, "a", rand.New(rand.NewPCG(1, 1)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(choice.Options) != 2 {
+		t.Errorf("Expected 2 options, got %v", choice.Options)
+	}
+	if choice.Options[choice.Correct] != "alpha" {
+		t.Errorf("Expected correct option 'alpha', got %q", choice.Options[choice.Correct])
+	}
+}
+
+func TestBuildChoiceUnknownCard(t *testing.T) {
+	_, err := BuildChoice(createChoiceDeck(), "missing", rand.New(rand.NewPCG(1, 1)))
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+}
+
+func TestChoiceScore(t *testing.T) {
+	choice := Choice{Options: []string{"a", "b", "c", "d"}, Correct: 2}
+	if score := choice.Score(2); score != ChoiceCorrectScore {
+		t.Errorf("Expected score %d for the right option, got %d", ChoiceCorrectScore, score)
+	}
+	if score := choice.Score(0); score != ChoiceWrongScore {
+		t.Errorf("Expected score %d for a wrong option, got %d", ChoiceWrongScore, score)
+	}
+}
@@ -1,56 +1,213 @@
 package service
 
 import (
-	"sort"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/telikz/spacdr/internal/domain"
 	"github.com/telikz/spacdr/internal/repo"
+	"github.com/telikz/spacdr/internal/scheduler"
 )
 
 type DeckService interface {
 	LoadDeck(filePath string) (*domain.Deck, error)
 	SaveDeck(filePath string, deck *domain.Deck) error
-	RateCard(deck *domain.Deck, cardIndex int, score int) error
+	RateCard(deck *domain.Deck, cardID string, score int, timeSpent time.Duration) error
+	RateLearningStep(deck *domain.Deck, cardID string, score int, timeSpent time.Duration) error
 	SortCardsByScore(deck *domain.Deck)
+	StudyQueue(deck *domain.Deck) []string
+	DueQueue(deck *domain.Deck, now time.Time) ([]string, error)
+	Due(deck *domain.Deck, now time.Time) (DueSummary, error)
+	LimitsFor(deck *domain.Deck) Limits
 	NextCard(deck *domain.Deck, current int) int
 	PreviousCard(current int) int
 	AdjustCardScoresByReviewDate(deck *domain.Deck)
+	UndoRating(deck *domain.Deck, cardID string, before domain.CardState) error
+	EditCard(deck *domain.Deck, cardID string, entry domain.Card) error
+	DeleteCard(deck *domain.Deck, cardID string) error
+	SuspendCard(deck *domain.Deck, cardID string) error
+	UnsuspendCard(deck *domain.Deck, cardID string) error
+	BuryCard(deck *domain.Deck, cardID string, now time.Time) error
+	Leeches(deck *domain.Deck) ([]Leech, error)
+	ReviewHistory(deck *domain.Deck) ([]domain.ReviewLog, error)
+	Stats(deck *domain.Deck, now time.Time) (*Stats, error)
+	SessionSummary(deck *domain.Deck, since, now time.Time) (SessionSummary, error)
 }
 
+var ErrCardNotFound = errors.New("card not found")
+
 type DeckServiceImpl struct {
-	repo repo.DeckRepository
+	repo      repo.DeckRepository
+	reviews   repo.ReviewLogRepository
+	scheduler scheduler.Scheduler
+	limits    Limits
+	tags      domain.TagFilter
+
+	leechThreshold int
+	leechSuspend   bool
+}
+
+type Option func(*DeckServiceImpl)
+
+// WithScheduler sets the scheduler used for decks that don't pick their own.
+func WithScheduler(s scheduler.Scheduler) Option {
+	return func(svc *DeckServiceImpl) {
+		svc.scheduler = s
+	}
+}
+
+// WithReviewLog records every rating in the given review log.
+func WithReviewLog(reviews repo.ReviewLogRepository) Option {
+	return func(svc *DeckServiceImpl) {
+		svc.reviews = reviews
+	}
 }
 
-func NewDeckService(repo repo.DeckRepository) DeckService {
-	return &DeckServiceImpl{repo: repo}
+// WithTagFilter restricts study queues to the cards matching the filter.
+func WithTagFilter(f domain.TagFilter) Option {
+	return func(svc *DeckServiceImpl) {
+		svc.tags = f
+	}
+}
+
+func NewDeckService(repo repo.DeckRepository, opts ...Option) DeckService {
+	svc := &DeckServiceImpl{
+		repo:      repo,
+		scheduler: scheduler.NewSM2(),
+		limits: Limits{
+			NewCardsPerDay: DefaultNewCardsPerDay,
+			ReviewsPerDay:  DefaultReviewsPerDay,
+		},
+		leechThreshold: DefaultLeechThreshold,
+	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+	return svc
 }
 
 func (s *DeckServiceImpl) LoadDeck(filePath string) (*domain.Deck, error) {
-	return s.repo.Load(filePath)
+	deck, err := s.repo.Load(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if deck.Scheduler != "" {
+		if _, err := scheduler.New(deck.Scheduler); err != nil {
+			return nil, fmt.Errorf("deck %q: %w", deck.Name, err)
+		}
+	}
+	if deck.Mode != "" && !domain.IsStudyMode(deck.Mode) {
+		return nil, fmt.Errorf("deck %q: unknown study mode %q (available: %v)", deck.Name, deck.Mode, domain.StudyModes())
+	}
+	if limits := s.LimitsFor(deck); limits.NewCardsPerDay < 0 || limits.ReviewsPerDay < 0 {
+		return nil, fmt.Errorf("deck %q: daily limits can't be negative", deck.Name)
+	}
+	return deck, nil
 }
 
 func (s *DeckServiceImpl) SaveDeck(filePath string, deck *domain.Deck) error {
 	return s.repo.Save(filePath, deck)
 }
 
-func (s *DeckServiceImpl) RateCard(deck *domain.Deck, cardIndex int, score int) error {
-	if cardIndex < 0 || cardIndex >= len(deck.Cards) {
+// RateCard schedules the card according to score, counts failures of studied
+// cards as lapses and, when a review log is configured, records the rating.
+// timeSpent is how long the card was shown before it was rated. If the log
+// can't be written the card is left as it was.
+func (s *DeckServiceImpl) RateCard(deck *domain.Deck, cardID string, score int, timeSpent time.Duration) error {
+	return s.rate(deck, cardID, score, timeSpent, false)
+}
+
+// RateLearningStep records a rating of a card repeated at one of its learning
+// steps. The card was scheduled when it was first rated in the session, so the
+// repeat is logged without rescheduling it or counting a lapse; only
+// schedulers that model short-term memory, like FSRS, take it into account.
+func (s *DeckServiceImpl) RateLearningStep(deck *domain.Deck, cardID string, score int, timeSpent time.Duration) error {
+	return s.rate(deck, cardID, score, timeSpent, true)
+}
+
+func (s *DeckServiceImpl) rate(deck *domain.Deck, cardID string, score int, timeSpent time.Duration, repeat bool) error {
+	card := deck.CardByID(cardID)
+	if card == nil {
+		return fmt.Errorf("%w: %s", ErrCardNotFound, cardID)
+	}
+	if score < scheduler.MinScore || score > scheduler.MaxScore {
+		return fmt.Errorf("score must be between %d and %d, got %d", scheduler.MinScore, scheduler.MaxScore, score)
+	}
+
+	now := time.Now()
+	before := card.State()
+	sched := s.schedulerFor(deck)
+
+	if !repeat {
+		sched.Rate(card, score, now)
+	} else if short, ok := sched.(scheduler.ShortTermScheduler); ok {
+		short.RateShortTerm(card, score, now)
+	}
+	card.Score = score
+	card.LastReview = now
+	if !repeat {
+		s.trackLapse(card, before, score)
+	}
+
+	if s.reviews == nil {
 		return nil
 	}
 
-	deck.Cards[cardIndex].Score = score
-	deck.Cards[cardIndex].LastReview = time.Now()
+	entry := domain.ReviewLog{
+		CardID:     card.ID,
+		ReviewedAt: now,
+		Rating:     score,
+		Scheduler:  sched.Name(),
+		TimeSpent:  timeSpent,
+		Before:     before,
+		After:      card.State(),
+		Repeat:     repeat,
+	}
+	if !before.LastReview.IsZero() {
+		entry.Elapsed = now.Sub(before.LastReview)
+	}
+	if err := s.reviews.Append(deck.Path, entry); err != nil {
+		card.SetState(before)
+		return fmt.Errorf("error recording review: %w", err)
+	}
 	return nil
 }
 
+// SortCardsByScore orders the deck the way its scheduler wants it studied:
+// due cards first, scheduled cards after in order of their due date.
 func (s *DeckServiceImpl) SortCardsByScore(deck *domain.Deck) {
-	sort.Slice(deck.Cards, func(i, j int) bool {
-		if deck.Cards[i].Score != deck.Cards[j].Score {
-			return deck.Cards[i].Score < deck.Cards[j].Score
+	scheduler.Sort(s.schedulerFor(deck), deck.Cards, time.Now())
+}
+
+// StudyQueue returns the IDs of all the deck's cards matching the tag filter,
+// due or not, in the order its scheduler wants them studied. Suspended and
+// buried cards are left out, and the deck itself is left in file order. As
+// with DueQueue, only one card per note makes it in, and none of a note
+// studied today.
+func (s *DeckServiceImpl) StudyQueue(deck *domain.Deck) []string {
+	now := time.Now()
+	var cards []domain.Card
+	for i := range deck.Cards {
+		if s.tags.Match(&deck.Cards[i]) && deck.Cards[i].InRotation(now) {
+			cards = append(cards, deck.Cards[i])
 		}
-		return deck.Cards[i].LastReview.Before(deck.Cards[j].LastReview)
-	})
+	}
+	scheduler.Sort(s.schedulerFor(deck), cards, now)
+
+	// Without a readable review log no note counts as studied today; DueQueue
+	// reports the error.
+	_, _, buried, err := s.studiedOn(deck, now)
+	if err != nil {
+		buried = make(map[string]bool)
+	}
+	cards = pickSiblings(cards, len(cards), buried)
+
+	queue := make([]string, len(cards))
+	for i := range cards {
+		queue[i] = cards[i].ID
+	}
+	return queue
 }
 
 func (s *DeckServiceImpl) NextCard(deck *domain.Deck, current int) int {
@@ -67,13 +224,16 @@ func (s *DeckServiceImpl) PreviousCard(current int) int {
 	return current
 }
 
+// AdjustCardScoresByReviewDate decays the scores of legacy cards that were
+// rated before scheduling existed. Cards with a due date are left alone since
+// the scheduler already decides when they come back.
 func (s *DeckServiceImpl) AdjustCardScoresByReviewDate(deck *domain.Deck) {
 	now := time.Now()
 	oneWeek := 7 * 24 * time.Hour
 	oneMonth := 30 * 24 * time.Hour
 
 	for i := range deck.Cards {
-		if deck.Cards[i].LastReview.IsZero() {
+		if deck.Cards[i].LastReview.IsZero() || !deck.Cards[i].Due.IsZero() {
 			continue
 		}
 
@@ -88,3 +248,36 @@ func (s *DeckServiceImpl) AdjustCardScoresByReviewDate(deck *domain.Deck) {
 		}
 	}
 }
+
+// ReviewHistory returns the ratings recorded for the deck, oldest first,
+// leaving out those that were undone. It is empty when the service has no
+// review log.
+func (s *DeckServiceImpl) ReviewHistory(deck *domain.Deck) ([]domain.ReviewLog, error) {
+	if s.reviews == nil {
+		return nil, nil
+	}
+	logs, err := s.reviews.Load(deck.Path)
+	if err != nil {
+		return nil, err
+	}
+	return withoutUndone(logs), nil
+}
+
+func (s *DeckServiceImpl) Stats(deck *domain.Deck, now time.Time) (*Stats, error) {
+	logs, err := s.ReviewHistory(deck)
+	if err != nil {
+		return nil, fmt.Errorf("error loading review log: %w", err)
+	}
+	return ComputeStats(deck, logs, now), nil
+}
+
+// schedulerFor returns the scheduler chosen by the deck, falling back to the
+// service default. Deck choices are validated when the deck is loaded.
+func (s *DeckServiceImpl) schedulerFor(deck *domain.Deck) scheduler.Scheduler {
+	if deck.Scheduler != "" {
+		if sched, err := scheduler.New(deck.Scheduler); err == nil {
+			return sched
+		}
+	}
+	return s.scheduler
+}
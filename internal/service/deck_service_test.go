@@ -1,9 +1,13 @@
 package service
 
 import (
+	"errors"
+	"os"
 	"path/filepath"
+	"reflect"
 	"github.com/telikz/spacdr/internal/domain"
 	"github.com/telikz/spacdr/internal/repo"
+	"github.com/telikz/spacdr/internal/scheduler"
 	"testing"
 	"time"
 )
@@ -12,9 +16,9 @@ func createTestDeck() *domain.Deck {
 	return &domain.Deck{
 		Name: "Test Deck",
 		Cards: []domain.Card{
-			{Front: "Q1", Back: "A1", Score: 0, LastReview: time.Time{}},
-			{Front: "Q2", Back: "A2", Score: 2, LastReview: time.Now().Add(-24 * time.Hour)},
-			{Front: "Q3", Back: "A3", Score: 1, LastReview: time.Now().Add(-48 * time.Hour)},
+			{ID: "q1", Front: "Q1", Back: "A1", Score: 0, LastReview: time.Time{}},
+			{ID: "q2", Front: "Q2", Back: "A2", Score: 2, LastReview: time.Now().Add(-24 * time.Hour)},
+			{ID: "q3", Front: "Q3", Back: "A3", Score: 1, LastReview: time.Now().Add(-48 * time.Hour)},
 		},
 	}
 }
@@ -23,7 +27,7 @@ func TestDeckServiceRateCard(t *testing.T) {
 	svc := NewDeckService(repo.NewFileDeckRepository())
 	deck := createTestDeck()
 
-	err := svc.RateCard(deck, 0, 5)
+	err := svc.RateCard(deck, "q1", 5, 0)
 	if err != nil {
 		t.Errorf("Expected nil error, got %v", err)
 	}
@@ -36,18 +40,18 @@ func TestDeckServiceRateCard(t *testing.T) {
 	}
 }
 
-func TestDeckServiceRateCardInvalidIndex(t *testing.T) {
+func TestDeckServiceRateCardUnknownID(t *testing.T) {
 	svc := NewDeckService(repo.NewFileDeckRepository())
 	deck := createTestDeck()
 
-	err := svc.RateCard(deck, 10, 5)
-	if err != nil {
-		t.Errorf("Expected nil error for out of bounds, got %v", err)
+	err := svc.RateCard(deck, "missing", 5, 0)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("Expected ErrCardNotFound for unknown id, got %v", err)
 	}
 
-	err = svc.RateCard(deck, -1, 5)
-	if err != nil {
-		t.Errorf("Expected nil error for negative index, got %v", err)
+	err = svc.RateCard(deck, "", 5, 0)
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("Expected ErrCardNotFound for empty id, got %v", err)
 	}
 }
 
@@ -151,15 +155,15 @@ func TestDeckServiceRateMultipleCards(t *testing.T) {
 	svc := NewDeckService(repo.NewFileDeckRepository())
 	deck := createTestDeck()
 
-	err := svc.RateCard(deck, 0, 5)
+	err := svc.RateCard(deck, "q1", 5, 0)
 	if err != nil {
 		t.Errorf("Expected nil error, got %v", err)
 	}
-	err = svc.RateCard(deck, 1, 3)
+	err = svc.RateCard(deck, "q2", 3, 0)
 	if err != nil {
 		t.Errorf("Expected nil error, got %v", err)
 	}
-	err = svc.RateCard(deck, 2, 4)
+	err = svc.RateCard(deck, "q3", 4, 0)
 	if err != nil {
 		t.Errorf("Expected nil error, got %v", err)
 	}
@@ -174,3 +178,234 @@ func TestDeckServiceRateMultipleCards(t *testing.T) {
 		t.Errorf("Card 2: expected 4, got %d", deck.Cards[2].Score)
 	}
 }
+
+func TestDeckServiceRateCardSchedulesSM2(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createTestDeck()
+
+	for i, want := range []int{1, 6, 16} {
+		if err := svc.RateCard(deck, "q1", 5, 0); err != nil {
+			t.Fatalf("Review %d: unexpected error %v", i, err)
+		}
+		card := deck.Cards[0]
+		if card.Interval != want {
+			t.Errorf("Review %d: expected interval %d, got %d", i, want, card.Interval)
+		}
+		if card.Repetitions != i+1 {
+			t.Errorf("Review %d: expected %d repetitions, got %d", i, i+1, card.Repetitions)
+		}
+		expectedDue := card.LastReview.Add(time.Duration(want) * 24 * time.Hour)
+		if !card.Due.Equal(expectedDue) {
+			t.Errorf("Review %d: expected due %v, got %v", i, expectedDue, card.Due)
+		}
+	}
+
+	if deck.Cards[0].EaseFactor <= 2.5 {
+		t.Errorf("Expected ease factor to grow above 2.5, got %f", deck.Cards[0].EaseFactor)
+	}
+}
+
+func TestDeckServiceRateCardLapseResetsInterval(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createTestDeck()
+
+	for range 3 {
+		if err := svc.RateCard(deck, "q1", 4, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	easeBefore := deck.Cards[0].EaseFactor
+
+	if err := svc.RateCard(deck, "q1", 1, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	card := deck.Cards[0]
+	if card.Repetitions != 0 {
+		t.Errorf("Expected repetitions reset to 0, got %d", card.Repetitions)
+	}
+	if card.Interval != 1 {
+		t.Errorf("Expected interval 1 after lapse, got %d", card.Interval)
+	}
+	if card.EaseFactor >= easeBefore {
+		t.Errorf("Expected ease factor to drop below %f, got %f", easeBefore, card.EaseFactor)
+	}
+}
+
+func TestDeckServiceRateCardMinimumEase(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createTestDeck()
+
+	for range 10 {
+		if err := svc.RateCard(deck, "q1", 1, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if deck.Cards[0].EaseFactor != 1.3 {
+		t.Errorf("Expected ease factor floor of 1.3, got %f", deck.Cards[0].EaseFactor)
+	}
+}
+
+func TestDeckServiceRateCardInvalidScore(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createTestDeck()
+
+	if err := svc.RateCard(deck, "q1", 0, 0); err == nil {
+		t.Error("Expected error for score 0, got nil")
+	}
+	if err := svc.RateCard(deck, "q1", 6, 0); err == nil {
+		t.Error("Expected error for score 6, got nil")
+	}
+}
+
+func TestDeckServiceSortCardsByScoreDueFirst(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	now := time.Now()
+	deck := &domain.Deck{
+		Name: "Test Deck",
+		Cards: []domain.Card{
+			{Front: "Later", Back: "A1", Score: 1, Due: now.Add(48 * time.Hour)},
+			{Front: "Soon", Back: "A2", Score: 1, Due: now.Add(24 * time.Hour)},
+			{Front: "Overdue", Back: "A3", Score: 5, Due: now.Add(-time.Hour)},
+			{Front: "New", Back: "A4", Score: 0},
+		},
+	}
+
+	svc.SortCardsByScore(deck)
+
+	expected := []string{"New", "Overdue", "Soon", "Later"}
+	for i, front := range expected {
+		if deck.Cards[i].Front != front {
+			t.Errorf("Position %d: expected %s, got %s", i, front, deck.Cards[i].Front)
+		}
+	}
+}
+
+func TestDeckServiceUsesDeckScheduler(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithScheduler(scheduler.NewFSRS()))
+
+	leitnerDeck := createTestDeck()
+	leitnerDeck.Scheduler = scheduler.LeitnerName
+	if err := svc.RateCard(leitnerDeck, "q1", 5, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if leitnerDeck.Cards[0].Box != 2 {
+		t.Errorf("Expected Leitner box 2, got %d", leitnerDeck.Cards[0].Box)
+	}
+
+	defaultDeck := createTestDeck()
+	if err := svc.RateCard(defaultDeck, "q1", 5, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if defaultDeck.Cards[0].Stability == 0 {
+		t.Error("Expected the service's FSRS scheduler to set stability")
+	}
+}
+
+func TestDeckServiceLoadDeckUnknownScheduler(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "unknown.json")
+	r := repo.NewFileDeckRepository()
+	svc := NewDeckService(r)
+
+	deck := createTestDeck()
+	deck.Scheduler = "supermemo-18"
+	if err := r.Save(filePath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+
+	if _, err := svc.LoadDeck(filePath); err == nil {
+		t.Error("Expected error for unknown scheduler, got nil")
+	}
+}
+
+func TestDeckServiceRateCardRecordsReview(t *testing.T) {
+	tmpDir := t.TempDir()
+	logs := repo.NewFileReviewLogRepository()
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(logs))
+	deck := createTestDeck()
+	deck.Path = filepath.Join(tmpDir, "deck.json")
+
+	if err := svc.RateCard(deck, "q2", 4, 3*time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err := logs.Load(deck.Path)
+	if err != nil {
+		t.Fatalf("Failed to load review log: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 review log entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.CardID != "q2" {
+		t.Errorf("Expected entry for Q2, got card id %s", entry.CardID)
+	}
+	if entry.Rating != 4 || entry.Scheduler != scheduler.SM2Name {
+		t.Errorf("Unexpected rating or scheduler: %+v", entry)
+	}
+	if entry.TimeSpent != 3*time.Second {
+		t.Errorf("Expected time spent 3s, got %v", entry.TimeSpent)
+	}
+	if entry.Elapsed < 24*time.Hour {
+		t.Errorf("Expected elapsed time of at least a day, got %v", entry.Elapsed)
+	}
+	if entry.Before.Score != 2 || entry.After.Score != 4 {
+		t.Errorf("Expected score 2 -> 4, got %d -> %d", entry.Before.Score, entry.After.Score)
+	}
+	if entry.After.Due.IsZero() {
+		t.Error("Expected after state to include a due date")
+	}
+}
+
+func TestDeckServiceRateCardLogFailureKeepsCard(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(repo.NewFileReviewLogRepository()))
+	deck := createTestDeck()
+	notADir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notADir, nil, 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	deck.Path = filepath.Join(notADir, "deck.json")
+	before := deck.Cards[0]
+
+	if err := svc.RateCard(deck, "q1", 5, 0); err == nil {
+		t.Fatal("Expected error when the review log can't be written, got nil")
+	}
+	if !reflect.DeepEqual(deck.Cards[0], before) {
+		t.Errorf("Expected card to be unchanged, got %+v", deck.Cards[0])
+	}
+}
+
+func TestDeckServiceStudyQueueKeepsDeckOrder(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createTestDeck()
+
+	queue := svc.StudyQueue(deck)
+
+	expected := []string{"q1", "q3", "q2"}
+	for i, id := range expected {
+		if queue[i] != id {
+			t.Errorf("Position %d: expected %s, got %s", i, id, queue[i])
+		}
+	}
+	for i, id := range []string{"q1", "q2", "q3"} {
+		if deck.Cards[i].ID != id {
+			t.Errorf("Deck was reordered: position %d has %s", i, deck.Cards[i].ID)
+		}
+	}
+}
+
+func TestDeckServiceRateCardByIDAfterReorder(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createTestDeck()
+
+	svc.SortCardsByScore(deck)
+	if err := svc.RateCard(deck, "q2", 5, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if card := deck.CardByID("q2"); card.Score != 5 || card.Front != "Q2" {
+		t.Errorf("Expected Q2 to be rated 5, got %+v", card)
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+var ErrEmptyCard = errors.New("a card needs both a front and a back")
+
+// EditCard replaces the content of the entry the card belongs to, e.g. both
+// directions of a reversed card or every deletion of a cloze. Scheduling is
+// left alone: whatever progress entry carries is ignored.
+func (s *DeckServiceImpl) EditCard(deck *domain.Deck, cardID string, entry domain.Card) error {
+	if deck.CardByID(cardID) == nil {
+		return fmt.Errorf("%w: %s", ErrCardNotFound, cardID)
+	}
+	isPlain := entry.Cloze == "" && entry.Type == "" && entry.Fields == nil
+	if isPlain && (entry.Front == "" || entry.Back == "") {
+		return ErrEmptyCard
+	}
+
+	entry.SetState(domain.CardState{})
+	if err := deck.ReplaceEntry(cardID, &entry); err != nil {
+		return fmt.Errorf("invalid card: %w", err)
+	}
+	return nil
+}
+
+// DeleteCard removes the entry the card belongs to, with all its cards.
+func (s *DeckServiceImpl) DeleteCard(deck *domain.Deck, cardID string) error {
+	if deck.CardByID(cardID) == nil {
+		return fmt.Errorf("%w: %s", ErrCardNotFound, cardID)
+	}
+	return deck.ReplaceEntry(cardID, nil)
+}
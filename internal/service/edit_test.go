@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/repo"
+)
+
+func createEditDeck(t *testing.T) *domain.Deck {
+	deck := &domain.Deck{
+		Name:    "Edit Deck",
+		Reverse: true,
+		Cards: []domain.Card{
+			{ID: "hola", Front: "Hola", Back: "Hello", Tags: []string{"greeting"}},
+			{ID: "cell", Cloze: "The {{c1::mitochondria}} is the {{c2::powerhouse}}"},
+		},
+	}
+	if err := deck.Expand(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return deck
+}
+
+func TestDeckServiceEditCardKeepsScheduling(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createEditDeck(t)
+	due := time.Now().Add(48 * time.Hour)
+	deck.CardByID("hola#r").SetState(domain.CardState{Score: 4, Due: due, Interval: 2})
+
+	entry, _ := deck.EntryOf("hola#r")
+	if entry.ID != "hola" || entry.Front != "Hola" || entry.Back != "Hello" {
+		t.Fatalf("Expected the forward entry, got %+v", entry)
+	}
+	entry.Back = "Hi"
+	entry.Score = 1
+	if err := svc.EditCard(deck, "hola#r", entry); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	forward, reverse := deck.CardByID("hola"), deck.CardByID("hola#r")
+	if forward == nil || reverse == nil {
+		t.Fatalf("Expected both directions to remain, got %v", deck.CardIDs())
+	}
+	if forward.Back != "Hi" || reverse.Front != "Hi" {
+		t.Errorf("Expected both directions to be edited, got %q and %q", forward.Back, reverse.Front)
+	}
+	if reverse.Score != 4 || !reverse.Due.Equal(due) || reverse.Interval != 2 {
+		t.Errorf("Expected scheduling to be kept, got %+v", reverse.State())
+	}
+	if !forward.HasTag("greeting") {
+		t.Error("Expected tags to be kept")
+	}
+}
+
+func TestDeckServiceEditClozeDropsRemovedDeletions(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createEditDeck(t)
+	deck.CardByID("cell#c1").Score = 3
+
+	entry, _ := deck.EntryOf("cell#c2")
+	entry.Cloze = "The {{c1::mitochondria}} makes energy"
+	if err := svc.EditCard(deck, "cell#c2", entry); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if deck.CardByID("cell#c2") != nil {
+		t.Error("Expected the removed deletion's card to be gone")
+	}
+	if c1 := deck.CardByID("cell#c1"); c1 == nil || c1.Score != 3 || c1.Back != "The mitochondria makes energy" {
+		t.Errorf("Expected c1 to be edited and keep its score, got %+v", c1)
+	}
+}
+
+func TestDeckServiceEditCardInvalid(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createEditDeck(t)
+	ids := deck.CardIDs()
+
+	if err := svc.EditCard(deck, "hola", domain.Card{Front: "Hola"}); !errors.Is(err, ErrEmptyCard) {
+		t.Errorf("Expected ErrEmptyCard, got %v", err)
+	}
+	if err := svc.EditCard(deck, "cell#c1", domain.Card{Cloze: "no deletions"}); err == nil {
+		t.Error("Expected an error for a cloze without deletions")
+	}
+	if err := svc.EditCard(deck, "missing", domain.Card{Front: "Q", Back: "A"}); !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+
+	if got := deck.CardIDs(); len(got) != len(ids) {
+		t.Errorf("Expected the deck to be unchanged, got %v", got)
+	}
+	if deck.CardByID("hola").Back != "Hello" {
+		t.Error("Expected the card to be unchanged")
+	}
+}
+
+func TestDeckServiceDeleteCard(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createEditDeck(t)
+
+	if err := svc.DeleteCard(deck, "hola"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if deck.CardByID("hola") != nil || deck.CardByID("hola#r") != nil {
+		t.Errorf("Expected both directions to be deleted, got %v", deck.CardIDs())
+	}
+	if len(deck.Cards) != 2 {
+		t.Errorf("Expected the cloze cards to remain, got %v", deck.CardIDs())
+	}
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/telikz/spacdr/internal/scheduler"
+)
+
+// LearningSteps are the delays after which cards come back within a session
+// while they're being learned. New cards go through Learning, and cards
+// forgotten after being studied through Relearning. No steps means cards are
+// only shown once per session.
+type LearningSteps struct {
+	Learning   []time.Duration
+	Relearning []time.Duration
+}
+
+// DefaultLearningSteps brings new cards back after a minute and then ten, and
+// forgotten cards after ten minutes.
+var DefaultLearningSteps = LearningSteps{
+	Learning:   []time.Duration{time.Minute, 10 * time.Minute},
+	Relearning: []time.Duration{10 * time.Minute},
+}
+
+// LearningStep is where a card is in its learning steps.
+type LearningStep struct {
+	// Step indexes the step the card is waiting for.
+	Step       int
+	Relearning bool
+	// Due is when the card comes back.
+	Due time.Time
+}
+
+// ParseLearningSteps parses steps written as Go durations, e.g. "1m" or "1h30m".
+func ParseLearningSteps(steps []string) ([]time.Duration, error) {
+	parsed := make([]time.Duration, 0, len(steps))
+	for _, step := range steps {
+		d, err := time.ParseDuration(step)
+		if err != nil {
+			return nil, fmt.Errorf("invalid learning step %q: %w", step, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid learning step %q: must be positive", step)
+		}
+		parsed = append(parsed, d)
+	}
+	return parsed, nil
+}
+
+// Next returns where a card goes after being rated with score at now, given
+// the step it was at, nil if it wasn't learning. isNew is whether the card had
+// never been studied before this rating. It returns nil once the card
+// graduates: it passed its last step, or was rated 5.
+func (s LearningSteps) Next(current *LearningStep, isNew bool, score int, now time.Time) *LearningStep {
+	var next LearningStep
+	switch {
+	case current != nil:
+		next = *current
+	case isNew:
+	case score < scheduler.PassingScore:
+		next.Relearning = true
+	default:
+		return nil
+	}
+
+	steps := s.Learning
+	if next.Relearning {
+		steps = s.Relearning
+	}
+	switch {
+	case len(steps) == 0, score == scheduler.MaxScore:
+		return nil
+	case score < scheduler.PassingScore:
+		next.Step = 0
+	default:
+		next.Step++
+		if next.Step >= len(steps) {
+			return nil
+		}
+	}
+	next.Due = now.Add(steps[next.Step])
+	return &next
+}
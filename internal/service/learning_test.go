@@ -0,0 +1,162 @@
+package service
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/repo"
+	"github.com/telikz/spacdr/internal/scheduler"
+)
+
+func TestLearningStepsNewCard(t *testing.T) {
+	steps := DefaultLearningSteps
+	now := time.Now()
+
+	step := steps.Next(nil, true, 1, now)
+	if step == nil || step.Step != 0 || step.Relearning || !step.Due.Equal(now.Add(time.Minute)) {
+		t.Fatalf("Expected a failed new card back after 1m, got %+v", step)
+	}
+	step = steps.Next(step, false, 3, now)
+	if step == nil || step.Step != 1 || !step.Due.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("Expected a passed step to wait for the 10m step, got %+v", step)
+	}
+	if step = steps.Next(step, false, 4, now); step != nil {
+		t.Errorf("Expected the card to graduate after its last step, got %+v", step)
+	}
+}
+
+func TestLearningStepsNewCardPassed(t *testing.T) {
+	now := time.Now()
+	step := DefaultLearningSteps.Next(nil, true, 4, now)
+	if step == nil || step.Step != 1 {
+		t.Fatalf("Expected a new card passed at first sight to skip the first step, got %+v", step)
+	}
+	if step := DefaultLearningSteps.Next(nil, true, 5, now); step != nil {
+		t.Errorf("Expected a new card rated 5 to graduate right away, got %+v", step)
+	}
+}
+
+func TestLearningStepsFailureRestarts(t *testing.T) {
+	now := time.Now()
+	step := DefaultLearningSteps.Next(&LearningStep{Step: 1}, false, 2, now)
+	if step == nil || step.Step != 0 || !step.Due.Equal(now.Add(time.Minute)) {
+		t.Errorf("Expected a failure to go back to the first step, got %+v", step)
+	}
+}
+
+func TestLearningStepsRelearning(t *testing.T) {
+	steps := DefaultLearningSteps
+	now := time.Now()
+
+	if step := steps.Next(nil, false, 4, now); step != nil {
+		t.Errorf("Expected a passed review to leave the session, got %+v", step)
+	}
+	step := steps.Next(nil, false, 1, now)
+	if step == nil || !step.Relearning || step.Step != 0 || !step.Due.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("Expected a forgotten card to relearn after 10m, got %+v", step)
+	}
+	if step = steps.Next(step, false, 3, now); step != nil {
+		t.Errorf("Expected the card to graduate after relearning, got %+v", step)
+	}
+}
+
+func TestLearningStepsDisabled(t *testing.T) {
+	var steps LearningSteps
+	if step := steps.Next(nil, true, 1, time.Now()); step != nil {
+		t.Errorf("Expected no learning without steps, got %+v", step)
+	}
+}
+
+func TestParseLearningSteps(t *testing.T) {
+	steps, err := ParseLearningSteps([]string{"1m", "1h30m"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := []time.Duration{time.Minute, 90 * time.Minute}; !slices.Equal(steps, want) {
+		t.Errorf("Expected %v, got %v", want, steps)
+	}
+	for _, invalid := range []string{"10", "-1m", "0s"} {
+		if _, err := ParseLearningSteps([]string{invalid}); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestRateLearningStepKeepsSchedule(t *testing.T) {
+	logs := repo.NewFileReviewLogRepository()
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(logs), WithLeechThreshold(3))
+	deck := createTestDeck()
+	deck.Path = filepath.Join(t.TempDir(), "deck.json")
+
+	// A new card passed, then passed again at its 10m step, is scheduled
+	// once.
+	if err := svc.RateCard(deck, "q1", 3, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	scheduled := deck.CardByID("q1").State()
+	if err := svc.RateLearningStep(deck, "q1", 3, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	card := deck.CardByID("q1")
+	if card.Interval != scheduled.Interval || card.Repetitions != scheduled.Repetitions || card.EaseFactor != scheduled.EaseFactor || !card.Due.Equal(scheduled.Due) {
+		t.Errorf("Expected the repeat not to reschedule the card, got %+v, was %+v", card.State(), scheduled)
+	}
+
+	// Failed repeats while relearning aren't lapses.
+	if err := svc.RateCard(deck, "q1", 1, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for range 3 {
+		if err := svc.RateLearningStep(deck, "q1", 1, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if card.Lapses != 1 || card.Leech {
+		t.Errorf("Expected 1 lapse and no leech, got %d lapses, leech %v", card.Lapses, card.Leech)
+	}
+
+	entries, err := logs.Load(deck.Path)
+	if err != nil {
+		t.Fatalf("Failed to load review log: %v", err)
+	}
+	if len(entries) != 6 {
+		t.Errorf("Expected every repeat to be logged, got %d entries", len(entries))
+	}
+}
+
+func TestRateLearningStepFSRSShortTerm(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithScheduler(scheduler.NewFSRS()))
+	deck := createTestDeck()
+
+	if err := svc.RateCard(deck, "q1", 3, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	card := deck.CardByID("q1")
+	stability, repetitions := card.Stability, card.Repetitions
+	if err := svc.RateLearningStep(deck, "q1", 4, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if card.Stability <= stability {
+		t.Errorf("Expected a passed repeat to raise stability above %v, got %v", stability, card.Stability)
+	}
+	if card.Repetitions != repetitions {
+		t.Errorf("Expected repetitions to stay %d, got %d", repetitions, card.Repetitions)
+	}
+}
+
+// rateNewCardAtSteps studies new card q1 the way a session does: failed on
+// first sight, then failed and passed at its learning steps.
+func rateNewCardAtSteps(t *testing.T, svc DeckService, deck *domain.Deck) {
+	t.Helper()
+	if err := svc.RateCard(deck, "q1", 1, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, score := range []int{1, 3} {
+		if err := svc.RateLearningStep(deck, "q1", score, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
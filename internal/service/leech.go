@@ -0,0 +1,88 @@
+package service
+
+import (
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/scheduler"
+)
+
+// DefaultLeechThreshold is the number of lapses that makes a card a leech.
+const DefaultLeechThreshold = 8
+
+// WithLeechThreshold sets how many lapses make a card a leech. 0 turns leech
+// detection off.
+func WithLeechThreshold(n int) Option {
+	return func(svc *DeckServiceImpl) {
+		svc.leechThreshold = n
+	}
+}
+
+// WithLeechSuspend suspends cards as soon as they become leeches.
+func WithLeechSuspend(suspend bool) Option {
+	return func(svc *DeckServiceImpl) {
+		svc.leechSuspend = suspend
+	}
+}
+
+// trackLapse counts a failed rating of a previously studied card as a lapse,
+// and marks the card as a leech once it has lapsed too often.
+func (s *DeckServiceImpl) trackLapse(card *domain.Card, before domain.CardState, score int) {
+	if score >= scheduler.PassingScore || before.LastReview.IsZero() {
+		return
+	}
+	card.Lapses++
+	if card.Leech || s.leechThreshold <= 0 || card.Lapses < s.leechThreshold {
+		return
+	}
+	card.Leech = true
+	if s.leechSuspend {
+		card.Suspended = true
+	}
+}
+
+// Leech is a card marked as a leech, with its failure history.
+type Leech struct {
+	CardID    string      `json:"card_id"`
+	Front     string      `json:"front"`
+	Lapses    int         `json:"lapses"`
+	Suspended bool        `json:"suspended"`
+	Reviews   int         `json:"reviews"`
+	Failures  []time.Time `json:"failures"`
+}
+
+// Leeches returns the deck's leeches in deck order, with the times they were
+// failed according to the review log.
+func (s *DeckServiceImpl) Leeches(deck *domain.Deck) ([]Leech, error) {
+	logs, err := s.ReviewHistory(deck)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	var leeches []Leech
+	for _, card := range deck.Cards {
+		if !card.Leech {
+			continue
+		}
+		index[card.ID] = len(leeches)
+		leeches = append(leeches, Leech{
+			CardID:    card.ID,
+			Front:     card.Front,
+			Lapses:    card.Lapses,
+			Suspended: card.Suspended,
+		})
+	}
+
+	for _, entry := range logs {
+		i, ok := index[entry.CardID]
+		if !ok {
+			continue
+		}
+		leeches[i].Reviews++
+		if entry.Rating < scheduler.PassingScore {
+			leeches[i].Failures = append(leeches[i].Failures, entry.ReviewedAt)
+		}
+	}
+	return leeches, nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/repo"
+)
+
+func TestDeckServiceRateCardCountsLapses(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createTestDeck()
+
+	// Failing a card the first time it is studied isn't a lapse.
+	if err := svc.RateCard(deck, "q1", 1, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lapses := deck.CardByID("q1").Lapses; lapses != 0 {
+		t.Errorf("Expected 0 lapses after the first rating, got %d", lapses)
+	}
+
+	for _, score := range []int{4, 2, 5, 1} {
+		if err := svc.RateCard(deck, "q1", score, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if lapses := deck.CardByID("q1").Lapses; lapses != 2 {
+		t.Errorf("Expected 2 lapses, got %d", lapses)
+	}
+}
+
+func TestDeckServiceMarksLeeches(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithLeechThreshold(3))
+	deck := createTestDeck()
+
+	for i := 0; i < 3; i++ {
+		if err := svc.RateCard(deck, "q1", 1, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	card := deck.CardByID("q1")
+	if card.Leech {
+		t.Fatalf("Expected no leech after %d lapses", card.Lapses)
+	}
+
+	if err := svc.RateCard(deck, "q1", 1, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !card.Leech {
+		t.Fatalf("Expected a leech after %d lapses", card.Lapses)
+	}
+	if !card.HasTag(domain.LeechTag) {
+		t.Error("Expected leech to be tagged leech")
+	}
+	if card.Suspended {
+		t.Error("Expected leech not to be suspended by default")
+	}
+}
+
+func TestDeckServiceSuspendsLeeches(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithLeechThreshold(1), WithLeechSuspend(true))
+	deck := createTestDeck()
+
+	for _, score := range []int{4, 1} {
+		if err := svc.RateCard(deck, "q2", score, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if card := deck.CardByID("q2"); !card.Leech || !card.Suspended {
+		t.Errorf("Expected a suspended leech, got %+v", card)
+	}
+}
+
+func TestDeckServiceLeechDetectionOff(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithLeechThreshold(0))
+	deck := createTestDeck()
+
+	for i := 0; i < 20; i++ {
+		if err := svc.RateCard(deck, "q1", 1, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if deck.CardByID("q1").Leech {
+		t.Error("Expected no leeches with a threshold of 0")
+	}
+}
+
+func TestDeckServiceLeeches(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(),
+		WithReviewLog(repo.NewFileReviewLogRepository()), WithLeechThreshold(2))
+	deck := createTestDeck()
+	deck.Path = filepath.Join(t.TempDir(), "deck.json")
+
+	for _, score := range []int{2, 4, 1, 3, 1} {
+		if err := svc.RateCard(deck, "q3", score, 0); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := svc.RateCard(deck, "q1", 1, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	leeches, err := svc.Leeches(deck)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(leeches) != 1 {
+		t.Fatalf("Expected 1 leech, got %+v", leeches)
+	}
+	leech := leeches[0]
+	if leech.CardID != "q3" || leech.Lapses != 3 {
+		t.Errorf("Expected q3 with 3 lapses, got %+v", leech)
+	}
+	if leech.Reviews != 5 || len(leech.Failures) != 3 {
+		t.Errorf("Expected 3 failures in 5 reviews, got %d in %d", len(leech.Failures), leech.Reviews)
+	}
+}
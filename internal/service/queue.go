@@ -0,0 +1,190 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/scheduler"
+)
+
+const (
+	DefaultNewCardsPerDay = 20
+	DefaultReviewsPerDay  = 200
+)
+
+// Limits caps how many cards of a deck are studied per day.
+type Limits struct {
+	NewCardsPerDay int `json:"new_cards_per_day"`
+	ReviewsPerDay  int `json:"reviews_per_day"`
+}
+
+// DueSummary counts what a study session started now would contain.
+type DueSummary struct {
+	// Reviews are previously studied cards whose due date has passed, up to
+	// what's left of today's review limit.
+	Reviews int `json:"reviews"`
+	// New are never studied cards still allowed today.
+	New int `json:"new"`
+	// ReviewsDone and NewDone count what was already studied today.
+	ReviewsDone int `json:"reviews_done"`
+	NewDone     int `json:"new_done"`
+	// Limits are the daily limits that apply to the deck.
+	Limits Limits `json:"limits"`
+	// NextDue is the earliest due date of the cards that aren't due yet.
+	NextDue time.Time `json:"next_due,omitzero"`
+}
+
+func (d DueSummary) Total() int {
+	return d.Reviews + d.New
+}
+
+// WithNewCardsPerDay limits how many never studied cards are introduced per
+// deck per day, unless the deck sets its own limit.
+func WithNewCardsPerDay(n int) Option {
+	return func(svc *DeckServiceImpl) {
+		svc.limits.NewCardsPerDay = n
+	}
+}
+
+// WithReviewsPerDay limits how many previously studied cards are reviewed per
+// deck per day, unless the deck sets its own limit.
+func WithReviewsPerDay(n int) Option {
+	return func(svc *DeckServiceImpl) {
+		svc.limits.ReviewsPerDay = n
+	}
+}
+
+func (s *DeckServiceImpl) Due(deck *domain.Deck, now time.Time) (DueSummary, error) {
+	reviews, newCards, summary, err := s.dueCards(deck, now)
+	if err != nil {
+		return DueSummary{}, err
+	}
+
+	summary.Reviews = len(reviews)
+	summary.New = len(newCards)
+	for i := range deck.Cards {
+		card := &deck.Cards[i]
+		if card.LastReview.IsZero() || card.IsDue(now) || card.Suspended || !s.tags.Match(card) {
+			continue
+		}
+		if summary.NextDue.IsZero() || card.Due.Before(summary.NextDue) {
+			summary.NextDue = card.Due
+		}
+	}
+	return summary, nil
+}
+
+// DueQueue returns the IDs of the cards to study now: the due reviews in the
+// order the deck's scheduler prefers, followed by today's new cards in deck
+// order. Both are capped by what's left of the deck's daily limits, and only
+// cards matching the tag filter that aren't suspended or buried are included.
+func (s *DeckServiceImpl) DueQueue(deck *domain.Deck, now time.Time) ([]string, error) {
+	reviews, newCards, _, err := s.dueCards(deck, now)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := make([]string, 0, len(reviews)+len(newCards))
+	for _, card := range reviews {
+		queue = append(queue, card.ID)
+	}
+	for _, card := range newCards {
+		queue = append(queue, card.ID)
+	}
+	return queue, nil
+}
+
+// LimitsFor returns the daily limits of the deck: its own where it sets them,
+// the service's otherwise.
+func (s *DeckServiceImpl) LimitsFor(deck *domain.Deck) Limits {
+	limits := s.limits
+	if deck.Limits != nil {
+		if deck.Limits.NewCardsPerDay != nil {
+			limits.NewCardsPerDay = *deck.Limits.NewCardsPerDay
+		}
+		if deck.Limits.ReviewsPerDay != nil {
+			limits.ReviewsPerDay = *deck.Limits.ReviewsPerDay
+		}
+	}
+	return limits
+}
+
+// dueCards picks the reviews and new cards to study now. Only one card per
+// note makes it in, and none of a note with a card already studied today, so
+// siblings such as the two directions of a reversed card are studied on
+// different days.
+func (s *DeckServiceImpl) dueCards(deck *domain.Deck, now time.Time) (reviews, newCards []domain.Card, summary DueSummary, err error) {
+	summary.Limits = s.LimitsFor(deck)
+	var buried map[string]bool
+	summary.ReviewsDone, summary.NewDone, buried, err = s.studiedOn(deck, now)
+	if err != nil {
+		return nil, nil, summary, err
+	}
+	newLeft := max(summary.Limits.NewCardsPerDay-summary.NewDone, 0)
+	reviewsLeft := max(summary.Limits.ReviewsPerDay-summary.ReviewsDone, 0)
+
+	var due, unseen []domain.Card
+	for _, card := range deck.Cards {
+		switch {
+		case !s.tags.Match(&card) || !card.InRotation(now):
+		case card.LastReview.IsZero():
+			unseen = append(unseen, card)
+		case card.IsDue(now):
+			due = append(due, card)
+		}
+	}
+
+	// Sort before capping so the cards the scheduler considers most urgent
+	// make the cut.
+	scheduler.Sort(s.schedulerFor(deck), due, now)
+	reviews = pickSiblings(due, reviewsLeft, buried)
+	newCards = pickSiblings(unseen, newLeft, buried)
+	return reviews, newCards, summary, nil
+}
+
+// pickSiblings returns up to limit of the cards, skipping those whose note is
+// in buried and burying the notes of those it picks.
+func pickSiblings(cards []domain.Card, limit int, buried map[string]bool) []domain.Card {
+	var picked []domain.Card
+	for _, card := range cards {
+		if len(picked) >= limit {
+			break
+		}
+		if card.Note != "" {
+			if buried[card.Note] {
+				continue
+			}
+			buried[card.Note] = true
+		}
+		picked = append(picked, card)
+	}
+	return picked
+}
+
+// studiedOn counts the ratings of the deck on the same day as now: reviews of
+// previously studied cards and cards studied for the first time, leaving out
+// repeats at learning steps. It also returns the notes those cards belong to.
+func (s *DeckServiceImpl) studiedOn(deck *domain.Deck, now time.Time) (reviews, newCards int, notes map[string]bool, err error) {
+	logs, err := s.ReviewHistory(deck)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("error loading review log: %w", err)
+	}
+
+	notes = make(map[string]bool)
+	today := startOfDay(now)
+	for _, entry := range logs {
+		if entry.ReviewedAt.Before(today) || entry.Repeat {
+			continue
+		}
+		if entry.Before.LastReview.IsZero() {
+			newCards++
+		} else {
+			reviews++
+		}
+		if card := deck.CardByID(entry.CardID); card != nil && card.Note != "" {
+			notes[card.Note] = true
+		}
+	}
+	return reviews, newCards, notes, nil
+}
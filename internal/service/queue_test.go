@@ -0,0 +1,316 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/repo"
+)
+
+func createDueDeck(now time.Time) *domain.Deck {
+	return &domain.Deck{
+		Name: "Due Deck",
+		Cards: []domain.Card{
+			{ID: "new1", Front: "N1", Back: "A"},
+			{ID: "later", Front: "L", Back: "A", Score: 5, LastReview: now.Add(-time.Hour), Due: now.Add(24 * time.Hour)},
+			{ID: "new2", Front: "N2", Back: "A"},
+			{ID: "overdue", Front: "O", Back: "A", Score: 4, LastReview: now.Add(-72 * time.Hour), Due: now.Add(-48 * time.Hour)},
+			{ID: "due", Front: "D", Back: "A", Score: 2, LastReview: now.Add(-48 * time.Hour), Due: now.Add(-time.Hour)},
+			{ID: "new3", Front: "N3", Back: "A"},
+		},
+	}
+}
+
+func TestDeckServiceDueQueue(t *testing.T) {
+	now := time.Now()
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithNewCardsPerDay(2))
+
+	queue, err := svc.DueQueue(createDueDeck(now), now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"due", "overdue", "new1", "new2"}
+	if len(queue) != len(expected) {
+		t.Fatalf("Expected queue %v, got %v", expected, queue)
+	}
+	for i, id := range expected {
+		if queue[i] != id {
+			t.Errorf("Position %d: expected %s, got %s", i, id, queue[i])
+		}
+	}
+}
+
+func TestDeckServiceDueQueueCountsNewCardsAcrossSessions(t *testing.T) {
+	now := time.Now()
+	logs := repo.NewFileReviewLogRepository()
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(logs), WithNewCardsPerDay(2))
+	deck := createDueDeck(now)
+	deck.Path = filepath.Join(t.TempDir(), "deck.json")
+
+	if err := svc.RateCard(deck, "new1", 4, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	yesterday := domain.ReviewLog{CardID: "x", ReviewedAt: now.Add(-24 * time.Hour), Rating: 3}
+	if err := logs.Append(deck.Path, yesterday); err != nil {
+		t.Fatalf("Failed to append log: %v", err)
+	}
+
+	summary, err := svc.Due(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.New != 1 {
+		t.Errorf("Expected 1 new card left today, got %d", summary.New)
+	}
+	if summary.Reviews != 2 {
+		t.Errorf("Expected 2 due reviews, got %d", summary.Reviews)
+	}
+
+	queue, err := svc.DueQueue(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if queue[len(queue)-1] != "new2" {
+		t.Errorf("Expected new2 to be the only new card, got queue %v", queue)
+	}
+}
+
+func TestDeckServiceDueNextDue(t *testing.T) {
+	now := time.Now()
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := &domain.Deck{Cards: []domain.Card{
+		{ID: "a", LastReview: now, Due: now.Add(48 * time.Hour)},
+		{ID: "b", LastReview: now, Due: now.Add(3 * time.Hour)},
+	}}
+
+	summary, err := svc.Due(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.Total() != 0 {
+		t.Errorf("Expected nothing due, got %+v", summary)
+	}
+	if !summary.NextDue.Equal(now.Add(3 * time.Hour)) {
+		t.Errorf("Expected next due in 3h, got %v", summary.NextDue)
+	}
+}
+
+func TestDeckServiceDueQueueReviewLimit(t *testing.T) {
+	now := time.Now()
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewsPerDay(1), WithNewCardsPerDay(0))
+
+	queue, err := svc.DueQueue(createDueDeck(now), now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(queue) != 1 || queue[0] != "due" {
+		t.Errorf("Expected only the most urgent review, got %v", queue)
+	}
+}
+
+func TestDeckServiceDeckLimitsOverrideConfig(t *testing.T) {
+	now := time.Now()
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewsPerDay(0), WithNewCardsPerDay(0))
+	deck := createDueDeck(now)
+	newCards, reviews := 3, 1
+	deck.Limits = &domain.DeckLimits{NewCardsPerDay: &newCards}
+
+	summary, err := svc.Due(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.New != 3 || summary.Reviews != 0 {
+		t.Errorf("Expected 3 new and no reviews, got %+v", summary)
+	}
+
+	deck.Limits.ReviewsPerDay = &reviews
+	summary, err = svc.Due(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.Reviews != 1 || summary.Limits.ReviewsPerDay != 1 {
+		t.Errorf("Expected the deck's review limit of 1, got %+v", summary)
+	}
+}
+
+func TestDeckServiceReviewLimitTrackedAcrossSessions(t *testing.T) {
+	now := time.Now()
+	logs := repo.NewFileReviewLogRepository()
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(logs), WithReviewsPerDay(2))
+	deck := createDueDeck(now)
+	deck.Path = filepath.Join(t.TempDir(), "deck.json")
+
+	earlier := domain.ReviewLog{
+		CardID:     "later",
+		ReviewedAt: now,
+		Rating:     5,
+		Before:     domain.CardState{LastReview: now.Add(-24 * time.Hour)},
+	}
+	if err := logs.Append(deck.Path, earlier); err != nil {
+		t.Fatalf("Failed to append log: %v", err)
+	}
+
+	summary, err := svc.Due(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.ReviewsDone != 1 || summary.Reviews != 1 {
+		t.Errorf("Expected 1 review done and 1 left, got %+v", summary)
+	}
+
+	tomorrow := now.Add(24 * time.Hour)
+	summary, err = svc.Due(deck, tomorrow)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.ReviewsDone != 0 || summary.Reviews != 2 {
+		t.Errorf("Expected the limit to reset the next day, got %+v", summary)
+	}
+}
+
+func TestDeckServiceLoadDeckNegativeLimit(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "limits.json")
+	r := repo.NewFileDeckRepository()
+	limit := -1
+	deck := createDueDeck(time.Now())
+	deck.Limits = &domain.DeckLimits{ReviewsPerDay: &limit}
+	if err := r.Save(filePath, deck); err != nil {
+		t.Fatalf("Failed to save deck: %v", err)
+	}
+
+	if _, err := NewDeckService(r).LoadDeck(filePath); err == nil {
+		t.Error("Expected error for negative limit, got nil")
+	}
+}
+
+func TestDeckServiceDueQueueBuriesSiblings(t *testing.T) {
+	now := time.Now()
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := &domain.Deck{
+		Name:    "Reverse Deck",
+		Reverse: true,
+		Cards: []domain.Card{
+			{ID: "hola", Front: "Hola", Back: "Hello"},
+			{ID: "adios", Front: "Adiós", Back: "Goodbye"},
+		},
+	}
+	if err := deck.Expand(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	deck.CardByID("adios#r").SetState(domain.CardState{Score: 3, LastReview: now.Add(-72 * time.Hour), Due: now.Add(-time.Hour)})
+
+	queue, err := svc.DueQueue(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"adios#r", "hola"}
+	if len(queue) != len(expected) {
+		t.Fatalf("Expected queue %v, got %v", expected, queue)
+	}
+	for i, id := range expected {
+		if queue[i] != id {
+			t.Errorf("Position %d: expected %s, got %s", i, id, queue[i])
+		}
+	}
+}
+
+func TestDeckServiceDueQueueBuriesSiblingsStudiedToday(t *testing.T) {
+	now := time.Now()
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(repo.NewFileReviewLogRepository()))
+	deck := &domain.Deck{
+		Name:    "Reverse Deck",
+		Reverse: true,
+		Cards:   []domain.Card{{ID: "hola", Front: "Hola", Back: "Hello"}},
+		Path:    filepath.Join(t.TempDir(), "deck.json"),
+	}
+	if err := deck.Expand(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := svc.RateCard(deck, "hola", 4, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	queue, err := svc.DueQueue(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(queue) != 0 {
+		t.Errorf("Expected the reverse card to wait for another day, got queue %v", queue)
+	}
+}
+
+func TestDeckServiceQueuesFilterTags(t *testing.T) {
+	now := time.Now()
+	deck := &domain.Deck{
+		Name: "Go",
+		Cards: []domain.Card{
+			{ID: "chan", Front: "Q1", Back: "A", Tags: []string{"concurrency"}},
+			{ID: "mutex", Front: "Q2", Back: "A", Tags: []string{"Concurrency", "sync"}},
+			{ID: "constraint", Front: "Q3", Back: "A", Tags: []string{"generics"}},
+			{ID: "untagged", Front: "Q4", Back: "A"},
+		},
+	}
+	svc := NewDeckService(repo.NewFileDeckRepository(),
+		WithTagFilter(domain.TagFilter{Include: []string{"concurrency", "generics"}, Exclude: []string{"sync"}}))
+
+	queue, err := svc.DueQueue(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := []string{"chan", "constraint"}
+	if len(queue) != len(expected) || queue[0] != expected[0] || queue[1] != expected[1] {
+		t.Errorf("Expected due queue %v, got %v", expected, queue)
+	}
+
+	all := svc.StudyQueue(deck)
+	if len(all) != 2 {
+		t.Errorf("Expected 2 cards in the study queue, got %v", all)
+	}
+}
+
+func TestDeckServiceStudyQueueBuriesSiblings(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(repo.NewFileReviewLogRepository()))
+	deck := &domain.Deck{
+		Name:    "Reverse Deck",
+		Reverse: true,
+		Cards: []domain.Card{
+			{ID: "hola", Front: "Hola", Back: "Hello"},
+			{ID: "adios", Front: "Adiós", Back: "Goodbye"},
+		},
+		Path: filepath.Join(t.TempDir(), "deck.json"),
+	}
+	if err := deck.Expand(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	queue := svc.StudyQueue(deck)
+	if len(queue) != 2 || deck.CardByID(queue[0]).Note == deck.CardByID(queue[1]).Note {
+		t.Errorf("Expected one card per note, got %v", queue)
+	}
+
+	if err := svc.RateCard(deck, "hola", 4, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	queue = svc.StudyQueue(deck)
+	if len(queue) != 1 || deck.CardByID(queue[0]).Note != deck.CardByID("adios").Note {
+		t.Errorf("Expected only adios's note after studying hola, got %v", queue)
+	}
+}
+
+func TestDeckServiceReviewLimitSkipsLearningStepRepeats(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(repo.NewFileReviewLogRepository()))
+	deck := createTestDeck()
+	deck.Path = filepath.Join(t.TempDir(), "deck.json")
+	rateNewCardAtSteps(t, svc, deck)
+
+	summary, err := svc.Due(deck, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.NewDone != 1 || summary.ReviewsDone != 0 {
+		t.Errorf("Expected 1 new card and no reviews done, got %d and %d", summary.NewDone, summary.ReviewsDone)
+	}
+}
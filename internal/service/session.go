@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/scheduler"
+)
+
+// SessionSummary describes the ratings of a study session. Cards counts the
+// cards rated, and Reviewed the ratings, cards in learning steps being rated
+// more than once.
+type SessionSummary struct {
+	Cards    int `json:"cards"`
+	Reviewed int `json:"reviewed"`
+	// Ratings counts the ratings given, Ratings[0] being the number of 1s.
+	Ratings   []int         `json:"ratings"`
+	TimeSpent time.Duration `json:"time_spent"`
+	Passed    int           `json:"passed"`
+	Accuracy  float64       `json:"accuracy"`
+	// New counts cards studied for the first time, Relearned previously
+	// studied cards that were forgotten.
+	New       int `json:"new"`
+	Relearned int `json:"relearned"`
+	// DueNow is what a new session would contain right away, and NextDue the
+	// earliest due date of the cards that aren't due yet.
+	DueNow  int       `json:"due_now"`
+	NextDue time.Time `json:"next_due,omitzero"`
+}
+
+// SessionSummary summarizes the ratings recorded for the deck since the
+// session started. Undone ratings don't count.
+func (s *DeckServiceImpl) SessionSummary(deck *domain.Deck, since, now time.Time) (SessionSummary, error) {
+	logs, err := s.ReviewHistory(deck)
+	if err != nil {
+		return SessionSummary{}, fmt.Errorf("error loading review log: %w", err)
+	}
+
+	summary := SessionSummary{Ratings: make([]int, scheduler.MaxScore)}
+	cards := make(map[string]bool)
+	relearned := make(map[string]bool)
+	for _, entry := range logs {
+		if entry.ReviewedAt.Before(since) {
+			continue
+		}
+		cards[entry.CardID] = true
+		summary.Reviewed++
+		summary.TimeSpent += entry.TimeSpent
+		if entry.Rating >= scheduler.MinScore && entry.Rating <= scheduler.MaxScore {
+			summary.Ratings[entry.Rating-1]++
+		}
+
+		passed := entry.Rating >= scheduler.PassingScore
+		if passed {
+			summary.Passed++
+		}
+		switch {
+		case entry.Repeat:
+		case entry.Before.LastReview.IsZero():
+			summary.New++
+		case !passed && !relearned[entry.CardID]:
+			relearned[entry.CardID] = true
+			summary.Relearned++
+		}
+	}
+	summary.Cards = len(cards)
+	if summary.Reviewed > 0 {
+		summary.Accuracy = float64(summary.Passed) / float64(summary.Reviewed)
+	}
+
+	due, err := s.Due(deck, now)
+	if err != nil {
+		return SessionSummary{}, err
+	}
+	summary.DueNow = due.Total()
+	summary.NextDue = due.NextDue
+	return summary, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/repo"
+)
+
+func TestDeckServiceSessionSummary(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(repo.NewFileReviewLogRepository()))
+	deck := createTestDeck()
+	deck.Path = filepath.Join(t.TempDir(), "deck.json")
+
+	if err := svc.RateCard(deck, "q1", 4, 3*time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	started := time.Now()
+	ratings := []struct {
+		id    string
+		score int
+	}{{"q1", 5}, {"q2", 1}, {"q3", 2}, {"q3", 4}}
+	for _, r := range ratings {
+		if err := svc.RateCard(deck, r.id, r.score, 2*time.Second); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	before := deck.CardByID("q3").State()
+	if err := svc.RateCard(deck, "q3", 1, time.Second); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := svc.UndoRating(deck, "q3", before); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	summary, err := svc.SessionSummary(deck, started, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.Reviewed != 4 || summary.Cards != 3 {
+		t.Errorf("Expected 4 ratings of 3 cards since the session started, got %d of %d", summary.Reviewed, summary.Cards)
+	}
+	if want := []int{1, 1, 0, 1, 1}; !slices.Equal(summary.Ratings, want) {
+		t.Errorf("Expected ratings %v, got %v", want, summary.Ratings)
+	}
+	if summary.TimeSpent != 8*time.Second {
+		t.Errorf("Expected 8s spent, got %s", summary.TimeSpent)
+	}
+	if summary.Passed != 2 || summary.Accuracy != 0.5 {
+		t.Errorf("Expected 2 passed and 50%% accuracy, got %d and %.2f", summary.Passed, summary.Accuracy)
+	}
+	if summary.New != 0 {
+		t.Errorf("Expected no new cards, q1 was studied before the session, got %d", summary.New)
+	}
+	if summary.Relearned != 2 {
+		t.Errorf("Expected q2 and q3 to be relearned, got %d", summary.Relearned)
+	}
+	if summary.DueNow != 0 || summary.NextDue.IsZero() {
+		t.Errorf("Expected nothing due now and a next due date, got %d and %v", summary.DueNow, summary.NextDue)
+	}
+}
+
+func TestDeckServiceSessionSummaryWithoutRatings(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createTestDeck()
+
+	summary, err := svc.SessionSummary(deck, time.Now(), time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.Reviewed != 0 || summary.Accuracy != 0 {
+		t.Errorf("Expected an empty summary, got %+v", summary)
+	}
+	if summary.DueNow != 3 {
+		t.Errorf("Expected 3 cards due now, got %d", summary.DueNow)
+	}
+}
+
+func TestDeckServiceSessionSummaryLearningStepRepeats(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(repo.NewFileReviewLogRepository()))
+	deck := createTestDeck()
+	deck.Path = filepath.Join(t.TempDir(), "deck.json")
+	started := time.Now()
+	rateNewCardAtSteps(t, svc, deck)
+
+	summary, err := svc.SessionSummary(deck, started, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.Reviewed != 3 || summary.Cards != 1 {
+		t.Errorf("Expected 3 ratings of 1 card, got %d of %d", summary.Reviewed, summary.Cards)
+	}
+	if summary.New != 1 || summary.Relearned != 0 {
+		t.Errorf("Expected 1 new card and none relearned, got %d and %d", summary.New, summary.Relearned)
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/scheduler"
+)
+
+const (
+	// MatureInterval is the interval in days from which a card counts as
+	// mature rather than still being learned.
+	MatureInterval = 21
+
+	ForecastDays     = 30
+	reviewRateWindow = 30
+)
+
+// Retention is the share of reviews of previously studied cards that were
+// passed within a time window.
+type Retention struct {
+	Reviews int     `json:"reviews"`
+	Passed  int     `json:"passed"`
+	Rate    float64 `json:"rate"`
+}
+
+func (r *Retention) add(other Retention) {
+	r.Reviews += other.Reviews
+	r.Passed += other.Passed
+	r.Rate = 0
+	if r.Reviews > 0 {
+		r.Rate = float64(r.Passed) / float64(r.Reviews)
+	}
+}
+
+type Stats struct {
+	Total    int `json:"total"`
+	New      int `json:"new"`
+	Learning int `json:"learning"`
+	Mature   int `json:"mature"`
+	// Suspended counts cards out of rotation; they are also counted as new,
+	// learning or mature.
+	Suspended     int       `json:"suspended"`
+	Retention7d   Retention `json:"retention_7d"`
+	Retention30d  Retention `json:"retention_30d"`
+	AverageEase   float64   `json:"average_ease"`
+	ReviewsPerDay float64   `json:"reviews_per_day"`
+	// Forecast counts the cards due on each of the next ForecastDays days,
+	// with overdue cards counted today.
+	Forecast []int `json:"forecast"`
+
+	easeSum   float64
+	easeCards int
+	reviews30 int
+}
+
+// ComputeStats summarizes the deck's cards and the review history in logs as
+// of now.
+func ComputeStats(deck *domain.Deck, logs []domain.ReviewLog, now time.Time) *Stats {
+	stats := &Stats{Forecast: make([]int, ForecastDays)}
+	today := startOfDay(now)
+
+	for i := range deck.Cards {
+		card := &deck.Cards[i]
+		stats.Total++
+		if card.Suspended {
+			stats.Suspended++
+		}
+
+		switch {
+		case card.LastReview.IsZero():
+			stats.New++
+			continue
+		case card.Interval >= MatureInterval:
+			stats.Mature++
+		default:
+			stats.Learning++
+		}
+
+		if card.EaseFactor > 0 {
+			stats.easeSum += card.EaseFactor
+			stats.easeCards++
+		}
+
+		if card.Suspended {
+			continue
+		}
+		day := 0
+		if card.Due.After(today) {
+			day = int(card.Due.Sub(today) / (24 * time.Hour))
+		}
+		if day < ForecastDays {
+			stats.Forecast[day]++
+		}
+	}
+
+	var week, month Retention
+	for _, entry := range logs {
+		age := now.Sub(entry.ReviewedAt)
+		if age < 0 || age > reviewRateWindow*24*time.Hour {
+			continue
+		}
+		stats.reviews30++
+
+		if entry.Before.LastReview.IsZero() || entry.Repeat {
+			continue
+		}
+		passed := 0
+		if entry.Rating >= scheduler.PassingScore {
+			passed = 1
+		}
+		month.add(Retention{Reviews: 1, Passed: passed})
+		if age <= 7*24*time.Hour {
+			week.add(Retention{Reviews: 1, Passed: passed})
+		}
+	}
+	stats.Retention7d = week
+	stats.Retention30d = month
+
+	stats.finish()
+	return stats
+}
+
+// Add folds other into s, e.g. to total the stats of several decks.
+func (s *Stats) Add(other *Stats) {
+	s.Total += other.Total
+	s.New += other.New
+	s.Learning += other.Learning
+	s.Mature += other.Mature
+	s.Suspended += other.Suspended
+	s.Retention7d.add(other.Retention7d)
+	s.Retention30d.add(other.Retention30d)
+	s.easeSum += other.easeSum
+	s.easeCards += other.easeCards
+	s.reviews30 += other.reviews30
+
+	if s.Forecast == nil {
+		s.Forecast = make([]int, ForecastDays)
+	}
+	for i := range other.Forecast {
+		s.Forecast[i] += other.Forecast[i]
+	}
+	s.finish()
+}
+
+func (s *Stats) finish() {
+	s.AverageEase = 0
+	if s.easeCards > 0 {
+		s.AverageEase = s.easeSum / float64(s.easeCards)
+	}
+	s.ReviewsPerDay = float64(s.reviews30) / reviewRateWindow
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+func TestComputeStatsCardCounts(t *testing.T) {
+	now := time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)
+	deck := &domain.Deck{Cards: []domain.Card{
+		{ID: "new"},
+		{ID: "learning", LastReview: now.Add(-24 * time.Hour), Interval: 1, EaseFactor: 2.0, Due: now},
+		{ID: "mature", LastReview: now.Add(-24 * time.Hour), Interval: 30, EaseFactor: 3.0, Due: now.Add(29 * 24 * time.Hour)},
+		{ID: "overdue", LastReview: now.Add(-10 * 24 * time.Hour), Interval: 6, Due: now.Add(-4 * 24 * time.Hour)},
+		{ID: "later", LastReview: now, Interval: 60, Due: now.Add(60 * 24 * time.Hour)},
+	}}
+
+	stats := ComputeStats(deck, nil, now)
+
+	if stats.Total != 5 || stats.New != 1 || stats.Learning != 2 || stats.Mature != 2 {
+		t.Errorf("Unexpected counts: total %d, new %d, learning %d, mature %d",
+			stats.Total, stats.New, stats.Learning, stats.Mature)
+	}
+	if stats.AverageEase != 2.5 {
+		t.Errorf("Expected average ease 2.5, got %f", stats.AverageEase)
+	}
+	if len(stats.Forecast) != ForecastDays {
+		t.Fatalf("Expected %d forecast days, got %d", ForecastDays, len(stats.Forecast))
+	}
+	if stats.Forecast[0] != 2 {
+		t.Errorf("Expected 2 cards due today including overdue, got %d", stats.Forecast[0])
+	}
+	if stats.Forecast[29] != 1 {
+		t.Errorf("Expected 1 card due in 29 days, got %d", stats.Forecast[29])
+	}
+}
+
+func TestComputeStatsRetention(t *testing.T) {
+	now := time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)
+	reviewed := domain.CardState{LastReview: now.Add(-60 * 24 * time.Hour)}
+	logs := []domain.ReviewLog{
+		{ReviewedAt: now.Add(-time.Hour), Rating: 4, Before: reviewed},
+		{ReviewedAt: now.Add(-2 * 24 * time.Hour), Rating: 1, Before: reviewed},
+		{ReviewedAt: now.Add(-20 * 24 * time.Hour), Rating: 3, Before: reviewed},
+		{ReviewedAt: now.Add(-20 * 24 * time.Hour), Rating: 2, Before: reviewed},
+		{ReviewedAt: now.Add(-3 * time.Hour), Rating: 5},
+		{ReviewedAt: now.Add(-45 * 24 * time.Hour), Rating: 5, Before: reviewed},
+	}
+
+	stats := ComputeStats(&domain.Deck{}, logs, now)
+
+	if stats.Retention7d.Reviews != 2 || stats.Retention7d.Rate != 0.5 {
+		t.Errorf("Expected 7 day retention 50%% of 2, got %+v", stats.Retention7d)
+	}
+	if stats.Retention30d.Reviews != 4 || stats.Retention30d.Passed != 2 {
+		t.Errorf("Expected 30 day retention 2 of 4, got %+v", stats.Retention30d)
+	}
+	if stats.ReviewsPerDay != 5.0/30 {
+		t.Errorf("Expected %f reviews per day, got %f", 5.0/30, stats.ReviewsPerDay)
+	}
+}
+
+func TestStatsAdd(t *testing.T) {
+	now := time.Now()
+	a := ComputeStats(&domain.Deck{Cards: []domain.Card{
+		{LastReview: now, EaseFactor: 2.0, Interval: 1, Due: now},
+	}}, []domain.ReviewLog{
+		{ReviewedAt: now, Rating: 5, Before: domain.CardState{LastReview: now.Add(-time.Hour)}},
+	}, now)
+	b := ComputeStats(&domain.Deck{Cards: []domain.Card{
+		{},
+		{LastReview: now, EaseFactor: 3.0, Interval: 1, Due: now},
+	}}, []domain.ReviewLog{
+		{ReviewedAt: now, Rating: 1, Before: domain.CardState{LastReview: now.Add(-time.Hour)}},
+	}, now)
+
+	total := &Stats{}
+	total.Add(a)
+	total.Add(b)
+
+	if total.Total != 3 || total.New != 1 || total.Learning != 2 {
+		t.Errorf("Unexpected counts: %+v", total)
+	}
+	if total.AverageEase != 2.5 {
+		t.Errorf("Expected average ease 2.5, got %f", total.AverageEase)
+	}
+	if total.Retention7d.Reviews != 2 || total.Retention7d.Rate != 0.5 {
+		t.Errorf("Expected combined retention 50%% of 2, got %+v", total.Retention7d)
+	}
+	if total.Forecast[0] != 2 {
+		t.Errorf("Expected 2 cards due today, got %d", total.Forecast[0])
+	}
+}
+
+func TestComputeStatsRetentionSkipsLearningStepRepeats(t *testing.T) {
+	now := time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)
+	studied := domain.CardState{LastReview: now.Add(-20 * time.Minute)}
+	logs := []domain.ReviewLog{
+		{ReviewedAt: now.Add(-20 * time.Minute), Rating: 1},
+		{ReviewedAt: now.Add(-10 * time.Minute), Rating: 1, Before: studied, Repeat: true},
+		{ReviewedAt: now, Rating: 3, Before: studied, Repeat: true},
+	}
+
+	stats := ComputeStats(&domain.Deck{}, logs, now)
+
+	if stats.Retention7d.Reviews != 0 || stats.Retention30d.Reviews != 0 {
+		t.Errorf("Expected no reviews counted in retention, got %+v and %+v", stats.Retention7d, stats.Retention30d)
+	}
+	if stats.ReviewsPerDay != 3.0/30 {
+		t.Errorf("Expected every rating in reviews per day, got %f", stats.ReviewsPerDay)
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+// SuspendCard takes the card out of rotation until it is unsuspended. Its
+// scheduling is kept, so it picks up where it left off.
+func (s *DeckServiceImpl) SuspendCard(deck *domain.Deck, cardID string) error {
+	card := deck.CardByID(cardID)
+	if card == nil {
+		return fmt.Errorf("%w: %s", ErrCardNotFound, cardID)
+	}
+	card.Suspended = true
+	return nil
+}
+
+func (s *DeckServiceImpl) UnsuspendCard(deck *domain.Deck, cardID string) error {
+	card := deck.CardByID(cardID)
+	if card == nil {
+		return fmt.Errorf("%w: %s", ErrCardNotFound, cardID)
+	}
+	card.Suspended = false
+	return nil
+}
+
+// BuryCard keeps the card out of study for the rest of the day of now.
+func (s *DeckServiceImpl) BuryCard(deck *domain.Deck, cardID string, now time.Time) error {
+	card := deck.CardByID(cardID)
+	if card == nil {
+		return fmt.Errorf("%w: %s", ErrCardNotFound, cardID)
+	}
+	card.BuriedUntil = startOfDay(now).AddDate(0, 0, 1)
+	return nil
+}
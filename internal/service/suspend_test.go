@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/repo"
+)
+
+func TestDeckServiceSuspendedCardsLeaveQueues(t *testing.T) {
+	now := time.Now()
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createDueDeck(now)
+
+	if err := svc.SuspendCard(deck, "due"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := svc.SuspendCard(deck, "new1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	queue, err := svc.DueQueue(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, id := range queue {
+		if id == "due" || id == "new1" {
+			t.Errorf("Expected suspended card %s to be skipped, got queue %v", id, queue)
+		}
+	}
+	for _, id := range svc.StudyQueue(deck) {
+		if id == "due" || id == "new1" {
+			t.Errorf("Expected suspended card %s to be skipped by the study queue", id)
+		}
+	}
+
+	if err := svc.UnsuspendCard(deck, "due"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	queue, err = svc.DueQueue(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(queue) == 0 || queue[0] != "due" {
+		t.Errorf("Expected unsuspended card to be back first in the queue, got %v", queue)
+	}
+}
+
+func TestDeckServiceBuryCard(t *testing.T) {
+	now := time.Now()
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	deck := createDueDeck(now)
+
+	if err := svc.BuryCard(deck, "overdue", now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	card := deck.CardByID("overdue")
+	if !card.IsBuried(now) {
+		t.Fatal("Expected card to be buried")
+	}
+	tomorrow := startOfDay(now).AddDate(0, 0, 1)
+	if !card.BuriedUntil.Equal(tomorrow) {
+		t.Errorf("Expected card to be buried until %v, got %v", tomorrow, card.BuriedUntil)
+	}
+
+	queue, err := svc.DueQueue(deck, now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, id := range queue {
+		if id == "overdue" {
+			t.Errorf("Expected buried card to be skipped, got queue %v", queue)
+		}
+	}
+
+	queue, err = svc.DueQueue(deck, tomorrow)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !slices.Contains(queue, "overdue") {
+		t.Errorf("Expected buried card to be back the next day, got queue %v", queue)
+	}
+}
+
+func TestDeckServiceSuspendUnknownCard(t *testing.T) {
+	svc := NewDeckService(repo.NewFileDeckRepository())
+	if err := svc.SuspendCard(createTestDeck(), "missing"); !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+)
+
+// UndoRating takes back the card's latest rating, restoring the state it had
+// before, and records that in the review log so the rating no longer counts.
+// If the log can't be written the card is left as it was.
+func (s *DeckServiceImpl) UndoRating(deck *domain.Deck, cardID string, before domain.CardState) error {
+	card := deck.CardByID(cardID)
+	if card == nil {
+		return fmt.Errorf("%w: %s", ErrCardNotFound, cardID)
+	}
+
+	rated := card.State()
+	card.SetState(before)
+	if s.reviews == nil {
+		return nil
+	}
+
+	entry := domain.ReviewLog{
+		CardID:     card.ID,
+		ReviewedAt: time.Now(),
+		Scheduler:  s.schedulerFor(deck).Name(),
+		Before:     rated,
+		After:      before,
+		Undo:       true,
+	}
+	if err := s.reviews.Append(deck.Path, entry); err != nil {
+		card.SetState(rated)
+		return fmt.Errorf("error recording undo: %w", err)
+	}
+	return nil
+}
+
+// withoutUndone drops undo entries from logs together with the ratings they
+// take back: the latest rating of the same card that wasn't undone yet.
+func withoutUndone(logs []domain.ReviewLog) []domain.ReviewLog {
+	undone := make(map[int]bool)
+	latest := make(map[string][]int)
+	for i, entry := range logs {
+		if !entry.Undo {
+			latest[entry.CardID] = append(latest[entry.CardID], i)
+			continue
+		}
+		undone[i] = true
+		if ratings := latest[entry.CardID]; len(ratings) > 0 {
+			undone[ratings[len(ratings)-1]] = true
+			latest[entry.CardID] = ratings[:len(ratings)-1]
+		}
+	}
+	if len(undone) == 0 {
+		return logs
+	}
+
+	kept := make([]domain.ReviewLog, 0, len(logs)-len(undone))
+	for i, entry := range logs {
+		if !undone[i] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
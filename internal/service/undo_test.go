@@ -0,0 +1,80 @@
+package service
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/repo"
+)
+
+func TestDeckServiceUndoRating(t *testing.T) {
+	logs := repo.NewFileReviewLogRepository()
+	svc := NewDeckService(repo.NewFileDeckRepository(), WithReviewLog(logs))
+	deck := createTestDeck()
+	deck.Path = filepath.Join(t.TempDir(), "deck.json")
+
+	original := deck.CardByID("q2").State()
+	if err := svc.RateCard(deck, "q2", 5, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	afterFirst := deck.CardByID("q2").State()
+	if err := svc.RateCard(deck, "q2", 1, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if err := svc.UndoRating(deck, "q2", afterFirst); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state := deck.CardByID("q2").State(); !reflect.DeepEqual(state, afterFirst) {
+		t.Errorf("Expected state %+v after the first undo, got %+v", afterFirst, state)
+	}
+	if err := svc.UndoRating(deck, "q2", original); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if state := deck.CardByID("q2").State(); !reflect.DeepEqual(state, original) {
+		t.Errorf("Expected original state %+v after the second undo, got %+v", original, state)
+	}
+
+	history, err := svc.ReviewHistory(deck)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("Expected undone ratings to be left out of the history, got %+v", history)
+	}
+
+	raw, err := logs.Load(deck.Path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(raw) != 4 || !raw[3].Undo {
+		t.Errorf("Expected 2 ratings and 2 undo entries in the log, got %d entries", len(raw))
+	}
+
+	summary, err := svc.Due(deck, time.Now())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if summary.ReviewsDone != 0 {
+		t.Errorf("Expected undone ratings not to count towards today's reviews, got %d", summary.ReviewsDone)
+	}
+}
+
+func TestWithoutUndone(t *testing.T) {
+	logs := []domain.ReviewLog{
+		{CardID: "a", Rating: 3},
+		{CardID: "b", Rating: 4},
+		{CardID: "a", Rating: 1},
+		{CardID: "a", Undo: true},
+		{CardID: "b", Rating: 5},
+	}
+
+	kept := withoutUndone(logs)
+	expected := []domain.ReviewLog{logs[0], logs[1], logs[4]}
+	if !reflect.DeepEqual(kept, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, kept)
+	}
+}
@@ -0,0 +1,10 @@
+// Package schema publishes the JSON Schema of the deck file format, for
+// editors and tools that check decks outside of spacdr.
+package schema
+
+import _ "embed"
+
+// Deck is the JSON Schema of a deck file, as documented in deck.schema.json.
+//
+//go:embed deck.schema.json
+var Deck []byte
@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/telikz/spacdr/internal/domain"
+	"github.com/telikz/spacdr/internal/repo"
+	"github.com/telikz/spacdr/internal/scheduler"
+)
+
+type object struct {
+	Properties map[string]struct {
+		Enum    []string `json:"enum"`
+		Maximum *int     `json:"maximum"`
+	} `json:"properties"`
+}
+
+type deckSchema struct {
+	object
+	Defs map[string]object `json:"$defs"`
+}
+
+func loadSchema(t *testing.T) deckSchema {
+	t.Helper()
+	var s deckSchema
+	if err := json.Unmarshal(Deck, &s); err != nil {
+		t.Fatalf("Invalid schema: %v", err)
+	}
+	return s
+}
+
+// jsonFields returns the sorted names of the JSON fields of struct type t.
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestSchemaMatchesDeckFormat(t *testing.T) {
+	s := loadSchema(t)
+	tests := []struct {
+		name   string
+		schema object
+		typ    reflect.Type
+	}{
+		{"deck", s.object, reflect.TypeFor[domain.Deck]()},
+		{"card", s.Defs["card"], reflect.TypeFor[domain.Card]()},
+		{"note type", s.Defs["noteType"], reflect.TypeFor[domain.NoteType]()},
+	}
+	for _, tt := range tests {
+		got := slices.Sorted(maps.Keys(tt.schema.Properties))
+		if want := jsonFields(tt.typ); !slices.Equal(got, want) {
+			t.Errorf("Expected %s properties %v, got %v", tt.name, want, got)
+		}
+	}
+}
+
+func TestSchemaMatchesSupportedValues(t *testing.T) {
+	s := loadSchema(t)
+	if maximum := s.Properties["version"].Maximum; maximum == nil || *maximum != repo.DeckVersion {
+		t.Errorf("Expected the maximum version to be %d", repo.DeckVersion)
+	}
+	if got := s.Properties["scheduler"].Enum; !slices.Equal(got, scheduler.Names()) {
+		t.Errorf("Expected schedulers %v, got %v", scheduler.Names(), got)
+	}
+	if got := s.Properties["mode"].Enum; !slices.Equal(got, domain.StudyModes()) {
+		t.Errorf("Expected study modes %v, got %v", domain.StudyModes(), got)
+	}
+}